@@ -0,0 +1,151 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Config holds all runtime configuration for the API server. Values are
+// loaded from environment variables once at startup via Load.
+type Config struct {
+	// Port the HTTP server listens on.
+	Port string
+	// APIKey protects every route; requests must send it in X-API-Key.
+	APIKey string
+	// MasterKey protects the key-management admin endpoints; requests
+	// must send it in X-Master-Key. When unset those endpoints are
+	// disabled entirely.
+	MasterKey string
+	// DBPath is the sqlite file used by GORM for application data.
+	DBPath string
+	// WhatsmeowDBPath is the sqlite file used by the whatsmeow session store.
+	WhatsmeowDBPath string
+	// WebhookTimeoutSeconds bounds each webhook delivery attempt.
+	WebhookTimeoutSeconds int
+	// WebhookMaxAttempts is how many times a failed delivery is retried.
+	WebhookMaxAttempts int
+	// WebhookConcurrency caps concurrent webhook deliveries per instance.
+	// Zero removes the bound. Instances may override it individually.
+	WebhookConcurrency int
+	// LogLevel for the whatsmeow client loggers (DEBUG, INFO, WARN, ERROR).
+	LogLevel string
+	// CheckContactsBatchSize caps how many numbers go into one
+	// IsOnWhatsApp query; larger inputs are chunked.
+	CheckContactsBatchSize int
+	// CheckContactsDelayMs is the pause between chunks, to stay under
+	// WhatsApp's rate limits.
+	CheckContactsDelayMs int
+	// EventBufferSize is the capacity of the per-instance event queue
+	// between whatsmeow and the handlers.
+	EventBufferSize int
+	// EventOverflowPolicy is what happens when the queue is full: "drop"
+	// discards the event, "block" waits for space (and stalls whatsmeow).
+	EventOverflowPolicy string
+	// SendTimeoutSeconds bounds outgoing sends and media uploads; zero
+	// disables the bound. Requests may override it per call.
+	SendTimeoutSeconds int
+	// SendRetryTransient retries sends that fail with a transient
+	// connection error (stream replaced, websocket drop) before giving
+	// up. Requests may override it per call.
+	SendRetryTransient bool
+	// SendMaxRetries caps the extra attempts made for a transient send
+	// failure.
+	SendMaxRetries int
+	// MediaDir is where auto-downloaded incoming media is stored, one
+	// subdirectory per instance.
+	MediaDir string
+	// AutoDownloadMaxBytes caps the size of media fetched by the
+	// auto-download setting; larger files are skipped.
+	AutoDownloadMaxBytes int64
+	// RerequestFromPhone asks the paired phone to resend messages that
+	// failed to decrypt, in addition to whatsmeow's normal retry receipts.
+	RerequestFromPhone bool
+	// DefaultWebhookEvents is the subscription used when a webhook is
+	// created without an explicit events array.
+	DefaultWebhookEvents []string
+	// MaxInstances caps how many instances one process may hold. Each
+	// instance keeps a WebSocket connection, an event worker goroutine and
+	// a share of the sqlite session store, so budget roughly 10-20 MB of
+	// memory per connected instance. Zero disables the cap.
+	MaxInstances int
+}
+
+var current *Config
+
+// Load reads configuration from the environment, applying defaults for
+// anything unset, and caches the result for Get.
+func Load() *Config {
+	current = &Config{
+		Port:                   getEnv("PORT", "8080"),
+		APIKey:                 getEnv("API_KEY", ""),
+		MasterKey:              getEnv("MASTER_KEY", ""),
+		DBPath:                 getEnv("DB_PATH", "whatsapi.db"),
+		WhatsmeowDBPath:        getEnv("WHATSMEOW_DB_PATH", "whatsmeow.db"),
+		WebhookTimeoutSeconds:  getEnvInt("WEBHOOK_TIMEOUT_SECONDS", 15),
+		WebhookMaxAttempts:     getEnvInt("WEBHOOK_MAX_ATTEMPTS", 3),
+		WebhookConcurrency:     getEnvInt("WEBHOOK_CONCURRENCY", 0),
+		LogLevel:               getEnv("LOG_LEVEL", "INFO"),
+		SendTimeoutSeconds:     getEnvInt("SEND_TIMEOUT_SECONDS", 60),
+		EventBufferSize:        getEnvInt("EVENT_BUFFER_SIZE", 256),
+		EventOverflowPolicy:    getEnv("EVENT_OVERFLOW_POLICY", "drop"),
+		CheckContactsBatchSize: getEnvInt("CHECK_CONTACTS_BATCH_SIZE", 50),
+		CheckContactsDelayMs:   getEnvInt("CHECK_CONTACTS_DELAY_MS", 500),
+		SendRetryTransient:     getEnvBool("SEND_RETRY_TRANSIENT", false),
+		MediaDir:               getEnv("MEDIA_DIR", "./media"),
+		AutoDownloadMaxBytes:   int64(getEnvInt("AUTO_DOWNLOAD_MAX_BYTES", 20*1024*1024)),
+		SendMaxRetries:         getEnvInt("SEND_MAX_RETRIES", 2),
+		RerequestFromPhone:     getEnvBool("REREQUEST_FROM_PHONE", true),
+		DefaultWebhookEvents:   getEnvList("WEBHOOK_DEFAULT_EVENTS", []string{"message.received", "message.ack", "instance.connected", "instance.disconnected"}),
+		MaxInstances:           getEnvInt("MAX_INSTANCES", 50),
+	}
+	return current
+}
+
+// Get returns the loaded configuration, loading it on first use.
+func Get() *Config {
+	if current == nil {
+		return Load()
+	}
+	return current
+}
+
+func getEnv(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+func getEnvBool(key string, fallback bool) bool {
+	if v := os.Getenv(key); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+	}
+	return fallback
+}
+
+func getEnvList(key string, fallback []string) []string {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
+	}
+	parts := strings.Split(v, ",")
+	out := make([]string, 0, len(parts))
+	for _, p := range parts {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+func getEnvInt(key string, fallback int) int {
+	if v := os.Getenv(key); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			return n
+		}
+	}
+	return fallback
+}
@@ -0,0 +1,227 @@
+package controllers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/Layoxd/whatsapiGo/src/database"
+	"github.com/Layoxd/whatsapiGo/src/middleware"
+	"github.com/Layoxd/whatsapiGo/src/models"
+)
+
+// AdminController exposes operational debugging endpoints.
+type AdminController struct{}
+
+// NewAdminController creates the admin controller.
+func NewAdminController() *AdminController {
+	return &AdminController{}
+}
+
+// GetMigrationsStatus reports the schema state of the application
+// database: which model tables exist, which columns AutoMigrate would
+// still add, and whether running it would change anything. There is no
+// migration version yet; the field is reserved for when versioned
+// migrations land.
+func (ac *AdminController) GetMigrationsStatus(c *gin.Context) {
+	// Verify connectivity first so a broken DB reports as such instead of
+	// as a wall of missing tables.
+	sqlDB, err := database.DB.DB()
+	if err == nil {
+		err = sqlDB.Ping()
+	}
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"success": false, "error": "database unreachable: " + err.Error()})
+		return
+	}
+
+	migrator := database.DB.Migrator()
+	tables := make([]gin.H, 0)
+	pending := false
+	for _, model := range database.MigratedModels() {
+		stmt := &gorm.Statement{DB: database.DB}
+		if err := stmt.Parse(model); err != nil {
+			continue
+		}
+		info := gin.H{"table": stmt.Schema.Table}
+		if !migrator.HasTable(model) {
+			info["exists"] = false
+			pending = true
+			tables = append(tables, info)
+			continue
+		}
+		info["exists"] = true
+		missing := make([]string, 0)
+		for _, field := range stmt.Schema.Fields {
+			if field.DBName == "" || field.IgnoreMigration {
+				continue
+			}
+			if !migrator.HasColumn(model, field.DBName) {
+				missing = append(missing, field.DBName)
+			}
+		}
+		if len(missing) > 0 {
+			info["missing_columns"] = missing
+			pending = true
+		}
+		tables = append(tables, info)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{
+		"database_ok":       true,
+		"migration_version": nil,
+		"pending_changes":   pending,
+		"tables":            tables,
+	}})
+}
+
+// ListAPIKeys returns the stored API keys with their fingerprints. The
+// plaintext keys are never stored, so only the fingerprint is available
+// for matching a key against a leak.
+func (ac *AdminController) ListAPIKeys(c *gin.Context) {
+	var keys []models.APIKey
+	if err := database.DB.Order("created_at ASC").Find(&keys).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": keys})
+}
+
+// CreateAPIKey generates a new API key, stores its hash and returns the
+// plaintext exactly once. The caller must record it; it cannot be
+// recovered later.
+func (ac *AdminController) CreateAPIKey(c *gin.Context) {
+	var req struct {
+		Label string `json:"label"`
+	}
+	// The body is optional; a missing or empty body creates an unlabeled key.
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	plaintext := hex.EncodeToString(raw)
+	hash := middleware.HashAPIKey(plaintext)
+	key := models.APIKey{
+		ID:          uuid.NewString(),
+		Label:       req.Label,
+		KeyHash:     hash,
+		Fingerprint: hash[:12],
+	}
+	if err := database.DB.Create(&key).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"success": true, "data": gin.H{
+		"key":     key,
+		"api_key": plaintext,
+	}})
+}
+
+// RevokeAPIKey marks a stored key as revoked. Revoked keys stop
+// authenticating immediately but stay listed for audit.
+func (ac *AdminController) RevokeAPIKey(c *gin.Context) {
+	var key models.APIKey
+	if err := database.DB.First(&key, "id = ?", c.Param("keyId")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "API key not found"})
+		return
+	}
+	if key.Revoked {
+		c.JSON(http.StatusOK, gin.H{"success": true, "data": key})
+		return
+	}
+	now := time.Now()
+	key.Revoked = true
+	key.RevokedAt = &now
+	if err := database.DB.Save(&key).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": key})
+}
+
+// softDeletingModels pairs every model that uses GORM soft deletes with
+// its table name for the purge report. Keep this in sync when a model
+// gains a DeletedAt column.
+func softDeletingModels() []struct {
+	Table string
+	Model interface{}
+} {
+	return []struct {
+		Table string
+		Model interface{}
+	}{
+		{"instances", &models.Instance{}},
+		{"messages", &models.Message{}},
+		{"statuses", &models.Status{}},
+		{"webhooks", &models.Webhook{}},
+		{"message_templates", &models.MessageTemplate{}},
+	}
+}
+
+// PurgeSoftDeleted permanently removes soft-deleted rows older than the
+// given age across every soft-deleting model. dry_run reports the counts
+// without removing anything, so operators can check before committing;
+// actual purges are logged per table.
+func (ac *AdminController) PurgeSoftDeleted(c *gin.Context) {
+	var req struct {
+		// OlderThanDays keeps recently deleted rows recoverable; zero
+		// uses the 30-day default.
+		OlderThanDays int  `json:"older_than_days"`
+		DryRun        bool `json:"dry_run"`
+	}
+	// The body is optional; an empty body purges with the defaults.
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	if req.OlderThanDays < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "older_than_days must be zero or positive"})
+		return
+	}
+	days := req.OlderThanDays
+	if days == 0 {
+		days = 30
+	}
+	cutoff := time.Now().AddDate(0, 0, -days)
+
+	tables := gin.H{}
+	var total int64
+	for _, entry := range softDeletingModels() {
+		var count int64
+		database.DB.Unscoped().Model(entry.Model).
+			Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+			Count(&count)
+		if !req.DryRun && count > 0 {
+			result := database.DB.Unscoped().
+				Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+				Delete(entry.Model)
+			if result.Error != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "purging " + entry.Table + ": " + result.Error.Error()})
+				return
+			}
+			log.Printf("admin purge: removed %d soft-deleted rows from %s", count, entry.Table)
+		}
+		tables[entry.Table] = count
+		total += count
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{
+		"dry_run":         req.DryRun,
+		"older_than_days": days,
+		"cutoff":          cutoff,
+		"total":           total,
+		"tables":          tables,
+	}})
+}
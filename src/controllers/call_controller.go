@@ -0,0 +1,487 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	"google.golang.org/protobuf/proto"
+
+	waBinary "go.mau.fi/whatsmeow/binary"
+
+	"github.com/Layoxd/whatsapiGo/src/database"
+	"github.com/Layoxd/whatsapiGo/src/models"
+	"github.com/Layoxd/whatsapiGo/src/services"
+	"github.com/Layoxd/whatsapiGo/src/utils"
+)
+
+// CallController manages incoming-call behaviour: auto-reject rules,
+// whitelists and call logs.
+type CallController struct {
+	instanceController *InstanceController
+	webhookService     *services.WebhookService
+
+	// rejectMu guards lastRejectMessage, which tracks when the reject
+	// message was last sent per instance+caller for the cooldown.
+	rejectMu          sync.Mutex
+	lastRejectMessage map[string]time.Time
+}
+
+// NewCallController creates the call controller.
+func NewCallController(ic *InstanceController, ws *services.WebhookService) *CallController {
+	return &CallController{
+		instanceController: ic,
+		webhookService:     ws,
+		lastRejectMessage:  make(map[string]time.Time),
+	}
+}
+
+// GetCallSettings returns the call settings of an instance, creating the
+// default row on first access.
+func (cc *CallController) GetCallSettings(c *gin.Context) {
+	instanceID := c.Param("instanceId")
+	if _, ok := cc.instanceController.getInstance(instanceID); !ok {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "instance not found"})
+		return
+	}
+	settings := cc.loadSettings(instanceID)
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": settings})
+}
+
+// loadSettings fetches (or initializes) the call settings row.
+func (cc *CallController) loadSettings(instanceID string) *models.CallSettings {
+	var settings models.CallSettings
+	err := database.DB.Where("instance_id = ?", instanceID).First(&settings).Error
+	if err != nil {
+		settings = models.CallSettings{
+			InstanceID:            instanceID,
+			Whitelist:             "[]",
+			CustomMessages:        "{}",
+			RejectMessageCooldown: 300,
+		}
+		database.DB.Create(&settings)
+	}
+	return &settings
+}
+
+// UpdateCallSettings replaces the call settings of an instance.
+func (cc *CallController) UpdateCallSettings(c *gin.Context) {
+	instanceID := c.Param("instanceId")
+	if _, ok := cc.instanceController.getInstance(instanceID); !ok {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "instance not found"})
+		return
+	}
+	var req models.UpdateCallSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	settings := cc.loadSettings(instanceID)
+	if req.AutoReject != nil {
+		settings.AutoReject = *req.AutoReject
+	}
+	if req.Whitelist != nil {
+		whitelist, _ := json.Marshal(req.Whitelist)
+		settings.Whitelist = string(whitelist)
+	}
+	if req.CustomMessages != nil {
+		normalized := make(map[string]string, len(req.CustomMessages))
+		for key, text := range req.CustomMessages {
+			if key != "default" {
+				key = normalizeWhitelistEntry(key)
+				if key == "" {
+					c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "custom_messages keys must be \"default\" or a valid number/JID"})
+					return
+				}
+			}
+			normalized[key] = text
+		}
+		messages, _ := json.Marshal(normalized)
+		settings.CustomMessages = string(messages)
+	}
+	if req.RejectMessageCooldown != nil {
+		if *req.RejectMessageCooldown < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "reject_message_cooldown must not be negative"})
+			return
+		}
+		settings.RejectMessageCooldown = *req.RejectMessageCooldown
+	}
+	if req.RejectAudioCalls != nil {
+		settings.RejectAudioCalls = *req.RejectAudioCalls
+	}
+	if req.RejectVideoCalls != nil {
+		settings.RejectVideoCalls = *req.RejectVideoCalls
+	}
+	if req.RejectScheduleStart != nil {
+		settings.RejectScheduleStart = *req.RejectScheduleStart
+	}
+	if req.RejectScheduleEnd != nil {
+		settings.RejectScheduleEnd = *req.RejectScheduleEnd
+	}
+	if err := database.DB.Save(settings).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": settings})
+}
+
+// normalizeWhitelistEntry canonicalizes a whitelist entry: phone numbers
+// and user JIDs become the bare normalized number, LID entries keep their
+// @lid suffix so they cannot collide with phone numbers.
+func normalizeWhitelistEntry(entry string) string {
+	if strings.Contains(entry, "@") {
+		if jid, err := types.ParseJID(entry); err == nil {
+			if jid.Server == types.HiddenUserServer {
+				return jid.User + "@" + types.HiddenUserServer
+			}
+			return utils.NormalizePhone(jid.User)
+		}
+	}
+	return utils.NormalizePhone(entry)
+}
+
+// AddToWhitelist adds numbers/JIDs to the auto-reject whitelist without
+// touching the rest of the call settings.
+func (cc *CallController) AddToWhitelist(c *gin.Context) {
+	cc.updateWhitelist(c, true)
+}
+
+// RemoveFromWhitelist removes numbers/JIDs from the auto-reject whitelist.
+func (cc *CallController) RemoveFromWhitelist(c *gin.Context) {
+	cc.updateWhitelist(c, false)
+}
+
+// updateWhitelist applies an incremental add or remove to the stored
+// whitelist, normalizing and deduplicating entries the same way the
+// call-rejection matcher does.
+func (cc *CallController) updateWhitelist(c *gin.Context, add bool) {
+	instanceID := c.Param("instanceId")
+	if _, ok := cc.instanceController.getInstance(instanceID); !ok {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "instance not found"})
+		return
+	}
+	var req struct {
+		Entries []string `json:"entries" binding:"required,min=1"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	settings := cc.loadSettings(instanceID)
+	var stored []string
+	_ = json.Unmarshal([]byte(settings.Whitelist), &stored)
+
+	whitelist := make([]string, 0, len(stored)+len(req.Entries))
+	seen := make(map[string]bool, len(stored))
+	for _, entry := range stored {
+		key := normalizeWhitelistEntry(entry)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		whitelist = append(whitelist, key)
+	}
+	changed := 0
+	for _, entry := range req.Entries {
+		key := normalizeWhitelistEntry(entry)
+		if key == "" {
+			continue
+		}
+		if add {
+			if !seen[key] {
+				seen[key] = true
+				whitelist = append(whitelist, key)
+				changed++
+			}
+			continue
+		}
+		if seen[key] {
+			delete(seen, key)
+			for i, existing := range whitelist {
+				if existing == key {
+					whitelist = append(whitelist[:i], whitelist[i+1:]...)
+					break
+				}
+			}
+			changed++
+		}
+	}
+
+	encoded, _ := json.Marshal(whitelist)
+	if err := database.DB.Model(settings).Update("whitelist", string(encoded)).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{
+		"changed":   changed,
+		"whitelist": whitelist,
+	}})
+}
+
+// GetCallLogs lists the call log of an instance.
+func (cc *CallController) GetCallLogs(c *gin.Context) {
+	instanceID := c.Param("instanceId")
+	if _, ok := cc.instanceController.getInstance(instanceID); !ok {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "instance not found"})
+		return
+	}
+	var logs []models.CallLog
+	if err := database.DB.
+		Where("instance_id = ?", instanceID).
+		Order("timestamp DESC").
+		Limit(100).
+		Find(&logs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": logs})
+}
+
+// GetCallStats returns aggregate call counts over a time window (query
+// params "hours", default 24, max 720), computed from the call log.
+func (cc *CallController) GetCallStats(c *gin.Context) {
+	instanceID := c.Param("instanceId")
+	if _, ok := cc.instanceController.getInstance(instanceID); !ok {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "instance not found"})
+		return
+	}
+	hours := 24
+	if raw := c.Query("hours"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > 720 {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "hours must be between 1 and 720"})
+			return
+		}
+		hours = parsed
+	}
+	since := time.Now().Add(-time.Duration(hours) * time.Hour)
+
+	var rows []struct {
+		Action string
+		Count  int64
+	}
+	if err := database.DB.Model(&models.CallLog{}).
+		Select("action, COUNT(*) as count").
+		Where("instance_id = ? AND timestamp >= ?", instanceID, since).
+		Group("action").
+		Scan(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	counts := map[string]int64{
+		models.CallActionReceived:         0,
+		models.CallActionRejected:         0,
+		models.CallActionWhitelisted:      0,
+		models.CallActionScheduleRejected: 0,
+	}
+	for _, row := range rows {
+		counts[row.Action] = row.Count
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{
+		"since":             since,
+		"hours":             hours,
+		"received":          counts[models.CallActionReceived],
+		"rejected":          counts[models.CallActionRejected],
+		"whitelisted":       counts[models.CallActionWhitelisted],
+		"schedule_rejected": counts[models.CallActionScheduleRejected],
+	}})
+}
+
+// handleIncomingCall applies the instance's call settings to an incoming
+// call offer. Called from the instance event handler.
+func (cc *CallController) handleIncomingCall(instance *Instance, e *events.CallOffer) {
+	media := "audio"
+	if isVideoCall(e) {
+		media = "video"
+	}
+	cc.logCall(instance.ID, e, models.CallActionReceived)
+	cc.webhookService.Dispatch(instance.ID, "call.received", map[string]interface{}{
+		"instance_id": instance.ID,
+		"call_id":     e.CallID,
+		"caller":      e.From.ToNonAD().String(),
+		"media":       media,
+		"timestamp":   e.Timestamp,
+	})
+
+	settings := cc.loadSettings(instance.ID)
+	if cc.isScheduleRejected(settings) {
+		cc.rejectCall(instance, e)
+		cc.logCall(instance.ID, e, models.CallActionScheduleRejected)
+		return
+	}
+	if !shouldRejectCall(settings, media == "video") {
+		return
+	}
+	var whitelist []string
+	_ = json.Unmarshal([]byte(settings.Whitelist), &whitelist)
+	if cc.isNumberWhitelisted(e.From.ToNonAD(), whitelist) {
+		cc.logCall(instance.ID, e, models.CallActionWhitelisted)
+		return
+	}
+	cc.rejectCall(instance, e)
+	cc.logCall(instance.ID, e, models.CallActionRejected)
+	rejectMessage := ""
+	if cc.shouldSendRejectMessage(instance.ID, e.From.ToNonAD(), settings.RejectMessageCooldown) {
+		rejectMessage = cc.sendRejectMessage(instance, e, settings)
+	}
+	cc.webhookService.Dispatch(instance.ID, "call.rejected", map[string]interface{}{
+		"instance_id":    instance.ID,
+		"call_id":        e.CallID,
+		"caller":         e.From.ToNonAD().String(),
+		"media":          media,
+		"timestamp":      e.Timestamp,
+		"reject_message": rejectMessage,
+	})
+}
+
+// isScheduleRejected reports whether the current time falls outside the
+// configured acceptance window.
+func (cc *CallController) isScheduleRejected(settings *models.CallSettings) bool {
+	if settings.RejectScheduleStart == "" || settings.RejectScheduleEnd == "" {
+		return false
+	}
+	now := time.Now().Format("15:04")
+	start, end := settings.RejectScheduleStart, settings.RejectScheduleEnd
+	if start <= end {
+		return now < start || now > end
+	}
+	// Window wraps midnight.
+	return now < start && now > end
+}
+
+// callerWhitelistKey canonicalizes a caller JID the same way whitelist
+// entries are normalized: LID callers keep their @lid suffix, everyone
+// else becomes the bare normalized number.
+func callerWhitelistKey(caller types.JID) string {
+	if caller.Server == types.HiddenUserServer {
+		return caller.User + "@" + types.HiddenUserServer
+	}
+	return utils.NormalizePhone(caller.User)
+}
+
+// isNumberWhitelisted reports whether the caller is on the whitelist.
+// Both sides are normalized before comparing, so phone-only entries match
+// callers that arrive as full @s.whatsapp.net JIDs and LID entries match
+// LID callers.
+func (cc *CallController) isNumberWhitelisted(caller types.JID, whitelist []string) bool {
+	key := callerWhitelistKey(caller)
+	for _, entry := range whitelist {
+		if normalizeWhitelistEntry(entry) == key {
+			return true
+		}
+	}
+	return false
+}
+
+// rejectCall sends the reject stanza for a call offer.
+func (cc *CallController) rejectCall(instance *Instance, e *events.CallOffer) {
+	node := waBinary.Node{
+		Tag: "call",
+		Attrs: waBinary.Attrs{
+			"to": e.From,
+			"id": instance.Client.GenerateMessageID(),
+		},
+		Content: []waBinary.Node{{
+			Tag: "reject",
+			Attrs: waBinary.Attrs{
+				"call-id":      e.CallID,
+				"call-creator": e.CallCreator,
+				"count":        "0",
+			},
+		}},
+	}
+	if err := instance.Client.DangerousInternals().SendNode(node); err != nil {
+		return
+	}
+}
+
+// isVideoCall reports whether a call offer is for a video call: the
+// offer node carries a <video> child for video calls.
+func isVideoCall(e *events.CallOffer) bool {
+	if e.Data == nil {
+		return false
+	}
+	for _, child := range e.Data.GetChildren() {
+		if child.Tag == "video" {
+			return true
+		}
+	}
+	return false
+}
+
+// shouldRejectCall applies the call-type toggles on top of AutoReject,
+// which still rejects every call when enabled.
+func shouldRejectCall(settings *models.CallSettings, isVideo bool) bool {
+	if settings.AutoReject {
+		return true
+	}
+	if isVideo {
+		return settings.RejectVideoCalls
+	}
+	return settings.RejectAudioCalls
+}
+
+// shouldSendRejectMessage reports whether the explanation message may be
+// sent to this caller now, and records the send time when it may. Calls
+// are rejected either way; this only throttles the message.
+func (cc *CallController) shouldSendRejectMessage(instanceID string, caller types.JID, cooldownSeconds int) bool {
+	if cooldownSeconds <= 0 {
+		return true
+	}
+	key := instanceID + ":" + callerWhitelistKey(caller)
+	cc.rejectMu.Lock()
+	defer cc.rejectMu.Unlock()
+	if last, ok := cc.lastRejectMessage[key]; ok && time.Since(last) < time.Duration(cooldownSeconds)*time.Second {
+		return false
+	}
+	cc.lastRejectMessage[key] = time.Now()
+	return true
+}
+
+// resolveRejectMessage picks the reject message for a caller: the entry
+// keyed by the caller's normalized number/JID wins, falling back to
+// "default". Returns "" when neither is configured.
+func resolveRejectMessage(customMessages string, caller types.JID) string {
+	var messages map[string]string
+	if err := json.Unmarshal([]byte(customMessages), &messages); err != nil {
+		return ""
+	}
+	if text, ok := messages[callerWhitelistKey(caller)]; ok && text != "" {
+		return text
+	}
+	return messages["default"]
+}
+
+// sendRejectMessage sends the configured explanation message to the caller
+// and returns the message that was sent ("" when none is configured).
+func (cc *CallController) sendRejectMessage(instance *Instance, e *events.CallOffer, settings *models.CallSettings) string {
+	text := resolveRejectMessage(settings.CustomMessages, e.From.ToNonAD())
+	if text == "" {
+		return ""
+	}
+	chat := types.NewJID(e.From.User, types.DefaultUserServer)
+	ctx, cancel := sendContext(0)
+	defer cancel()
+	_, _ = instance.Client.SendMessage(ctx, chat, &waE2E.Message{
+		Conversation: proto.String(text),
+	})
+	return text
+}
+
+// logCall records a call log row.
+func (cc *CallController) logCall(instanceID string, e *events.CallOffer, action string) {
+	database.DB.Create(&models.CallLog{
+		InstanceID: instanceID,
+		CallID:     e.CallID,
+		CallerJID:  e.From.ToNonAD().String(),
+		Action:     action,
+		Timestamp:  e.Timestamp,
+	})
+}
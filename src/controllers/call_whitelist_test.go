@@ -0,0 +1,67 @@
+package controllers
+
+import (
+	"testing"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+func TestNormalizeWhitelistEntry(t *testing.T) {
+	tests := []struct {
+		entry string
+		want  string
+	}{
+		{"+55 (11) 99999-9999", "5511999999999"},
+		{"5511999999999", "5511999999999"},
+		{"5511999999999@s.whatsapp.net", "5511999999999"},
+		{"123456789012345@lid", "123456789012345@lid"},
+	}
+	for _, tt := range tests {
+		if got := normalizeWhitelistEntry(tt.entry); got != tt.want {
+			t.Errorf("normalizeWhitelistEntry(%q) = %q, want %q", tt.entry, got, tt.want)
+		}
+	}
+}
+
+func TestResolveRejectMessage(t *testing.T) {
+	caller := types.NewJID("5511999999999", types.DefaultUserServer)
+	messages := `{"default":"busy","5511999999999":"call you back"}`
+
+	if got := resolveRejectMessage(messages, caller); got != "call you back" {
+		t.Errorf("caller-specific message = %q, want %q", got, "call you back")
+	}
+	other := types.NewJID("5511888888888", types.DefaultUserServer)
+	if got := resolveRejectMessage(messages, other); got != "busy" {
+		t.Errorf("default fallback = %q, want %q", got, "busy")
+	}
+	if got := resolveRejectMessage("not json", caller); got != "" {
+		t.Errorf("invalid JSON = %q, want empty", got)
+	}
+}
+
+func TestIsNumberWhitelisted(t *testing.T) {
+	cc := &CallController{}
+	phoneCaller := types.NewJID("5511999999999", types.DefaultUserServer)
+	lidCaller := types.NewJID("123456789012345", types.HiddenUserServer)
+
+	tests := []struct {
+		name      string
+		caller    types.JID
+		whitelist []string
+		want      bool
+	}{
+		{"phone-only entry matches full JID caller", phoneCaller, []string{"+55 11 99999-9999"}, true},
+		{"full-JID entry matches caller", phoneCaller, []string{"5511999999999@s.whatsapp.net"}, true},
+		{"lid entry matches lid caller", lidCaller, []string{"123456789012345@lid"}, true},
+		{"lid entry does not match phone caller", phoneCaller, []string{"5511999999999@lid"}, false},
+		{"unrelated number does not match", phoneCaller, []string{"5511888888888"}, false},
+		{"empty whitelist", phoneCaller, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cc.isNumberWhitelisted(tt.caller, tt.whitelist); got != tt.want {
+				t.Errorf("isNumberWhitelisted(%s, %v) = %v, want %v", tt.caller, tt.whitelist, got, tt.want)
+			}
+		})
+	}
+}
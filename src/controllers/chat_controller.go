@@ -0,0 +1,124 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mau.fi/whatsmeow/types"
+
+	"github.com/Layoxd/whatsapiGo/src/database"
+	"github.com/Layoxd/whatsapiGo/src/models"
+	"github.com/Layoxd/whatsapiGo/src/utils"
+)
+
+// ChatController exposes the conversation (inbox) view aggregated from the
+// stored messages.
+type ChatController struct {
+	instanceController *InstanceController
+}
+
+// NewChatController creates the chat controller.
+func NewChatController(ic *InstanceController) *ChatController {
+	return &ChatController{instanceController: ic}
+}
+
+// chatSummary is one conversation in the inbox listing.
+type chatSummary struct {
+	ChatJID      string    `json:"chat_jid"`
+	IsGroup      bool      `json:"is_group"`
+	LastMessage  string    `json:"last_message"`
+	LastType     string    `json:"last_type"`
+	LastFromMe   bool      `json:"last_from_me"`
+	UnreadCount  int64     `json:"unread_count"`
+	LastActivity time.Time `json:"last_activity"`
+}
+
+// GetStarredMessages lists the starred messages of one chat from the
+// local store, newest first.
+func (cc *ChatController) GetStarredMessages(c *gin.Context) {
+	instanceID := c.Param("instanceId")
+	if _, ok := cc.instanceController.getInstance(instanceID); !ok {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "instance not found"})
+		return
+	}
+	chat, err := types.ParseJID(c.Param("chat"))
+	if err != nil || chat.User == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "invalid chat path parameter"})
+		return
+	}
+	var messages []models.Message
+	if err := database.DB.
+		Where("instance_id = ? AND chat_jid = ? AND starred = ?", instanceID, chat.String(), true).
+		Order("timestamp DESC").
+		Find(&messages).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": messages})
+}
+
+// GetChats lists recent conversations sorted by last activity.
+func (cc *ChatController) GetChats(c *gin.Context) {
+	instanceID := c.Param("instanceId")
+	if _, ok := cc.instanceController.getInstance(instanceID); !ok {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "instance not found"})
+		return
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	var total int64
+	database.DB.Model(&models.Message{}).
+		Where("instance_id = ?", instanceID).
+		Distinct("chat_jid").
+		Count(&total)
+	pagination := utils.NewPagination(page, limit, total)
+
+	type chatRow struct {
+		ChatJID      string
+		LastActivity time.Time
+		UnreadCount  int64
+	}
+	var rows []chatRow
+	err := database.DB.Model(&models.Message{}).
+		Select("chat_jid, MAX(timestamp) AS last_activity, SUM(CASE WHEN from_me = false AND status = ? THEN 1 ELSE 0 END) AS unread_count", models.MessageStatusReceived).
+		Where("instance_id = ?", instanceID).
+		Group("chat_jid").
+		Order("last_activity DESC").
+		Offset(pagination.Offset()).
+		Limit(pagination.Limit).
+		Scan(&rows).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	chats := make([]chatSummary, 0, len(rows))
+	for _, row := range rows {
+		summary := chatSummary{
+			ChatJID:      row.ChatJID,
+			IsGroup:      strings.HasSuffix(row.ChatJID, "@"+types.GroupServer),
+			UnreadCount:  row.UnreadCount,
+			LastActivity: row.LastActivity,
+		}
+		var last models.Message
+		if err := database.DB.
+			Where("instance_id = ? AND chat_jid = ?", instanceID, row.ChatJID).
+			Order("timestamp DESC").
+			First(&last).Error; err == nil {
+			summary.LastMessage = last.Content
+			summary.LastType = last.Type
+			summary.LastFromMe = last.FromMe
+		}
+		chats = append(chats, summary)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":    true,
+		"data":       chats,
+		"pagination": pagination,
+	})
+}
@@ -0,0 +1,382 @@
+package controllers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+
+	"github.com/Layoxd/whatsapiGo/src/config"
+	"github.com/Layoxd/whatsapiGo/src/database"
+	"github.com/Layoxd/whatsapiGo/src/middleware"
+	"github.com/Layoxd/whatsapiGo/src/models"
+	"github.com/Layoxd/whatsapiGo/src/utils"
+)
+
+// ContactController exposes contact lookups.
+type ContactController struct {
+	instanceController *InstanceController
+}
+
+// NewContactController creates the contact controller.
+func NewContactController(ic *InstanceController) *ContactController {
+	return &ContactController{instanceController: ic}
+}
+
+// getConnectedInstance mirrors the message controller helper.
+func (cc *ContactController) getConnectedInstance(c *gin.Context, instanceID string) (*Instance, bool) {
+	instance, ok := cc.instanceController.getInstance(instanceID)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "instance not found"})
+		return nil, false
+	}
+	if !instance.Client.IsLoggedIn() {
+		c.JSON(http.StatusConflict, gin.H{"success": false, "error": "instance is not connected"})
+		return nil, false
+	}
+	return instance, true
+}
+
+// GetContactInfo returns profile info and avatar for a single contact.
+func (cc *ContactController) GetContactInfo(c *gin.Context) {
+	instance, ok := cc.getConnectedInstance(c, c.Param("instanceId"))
+	if !ok {
+		return
+	}
+	jid := middleware.JIDFromContext(c)
+
+	info := models.ContactInfo{JID: jid.String(), Phone: jid.User}
+
+	userInfo, err := instance.Client.GetUserInfo([]types.JID{jid})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	if u, found := userInfo[jid]; found {
+		info.Status = u.Status
+		info.IsOnWhatsApp = len(u.Devices) > 0
+		info.IsBusiness = u.VerifiedName != nil
+	}
+	if contact, err := instance.Client.Store.Contacts.GetContact(jid); err == nil && contact.Found {
+		info.Name = contact.FullName
+		info.PushName = contact.PushName
+	}
+	if pic, err := instance.Client.GetProfilePictureInfo(jid, &whatsmeow.GetProfilePictureParams{Preview: true}); err == nil && pic != nil {
+		info.AvatarURL = pic.URL
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": info})
+}
+
+// GetContactIdentity returns the identity key fingerprints (safety
+// numbers) stored for a contact's devices.
+func (cc *ContactController) GetContactIdentity(c *gin.Context) {
+	instance, ok := cc.getConnectedInstance(c, c.Param("instanceId"))
+	if !ok {
+		return
+	}
+	jid := middleware.JIDFromContext(c)
+	if instance.Client.Store.ID == nil {
+		c.JSON(http.StatusConflict, gin.H{"success": false, "error": "instance is not paired"})
+		return
+	}
+
+	rows, err := database.WhatsmeowDB.Query(
+		"SELECT their_id, identity FROM whatsmeow_identity_keys WHERE our_jid = ? AND their_id LIKE ?",
+		instance.Client.Store.ID.String(), jid.User+":%",
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	identities := make([]gin.H, 0)
+	for rows.Next() {
+		var theirID string
+		var identity []byte
+		if err := rows.Scan(&theirID, &identity); err != nil {
+			continue
+		}
+		sum := sha256.Sum256(identity)
+		identities = append(identities, gin.H{
+			"address":     theirID,
+			"fingerprint": formatFingerprint(sum[:]),
+		})
+	}
+	if len(identities) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "no identity known for this contact yet"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{
+		"jid":        jid.ToNonAD().String(),
+		"identities": identities,
+	}})
+}
+
+// BatchContactInfo resolves profile info for many JIDs with a single
+// GetUserInfo round trip. JIDs that fail to parse or are unknown to
+// WhatsApp are reported separately instead of failing the request.
+func (cc *ContactController) BatchContactInfo(c *gin.Context) {
+	var req struct {
+		JIDs []string `json:"jids" binding:"required,min=1"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	instance, ok := cc.getConnectedInstance(c, c.Param("instanceId"))
+	if !ok {
+		return
+	}
+
+	jids := make([]types.JID, 0, len(req.JIDs))
+	invalid := make([]string, 0)
+	for _, raw := range req.JIDs {
+		if strings.ContainsRune(raw, '@') {
+			jid, err := types.ParseJID(raw)
+			if err != nil || jid.User == "" {
+				invalid = append(invalid, raw)
+				continue
+			}
+			jids = append(jids, jid)
+			continue
+		}
+		if phone := utils.NormalizePhone(raw); phone != "" {
+			jids = append(jids, utils.UserJID(phone))
+		} else {
+			invalid = append(invalid, raw)
+		}
+	}
+	if len(jids) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "no valid JIDs in request", "invalid": invalid})
+		return
+	}
+
+	userInfo, err := instance.Client.GetUserInfo(jids)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	contacts := make(map[string]models.ContactInfo, len(jids))
+	notFound := make([]string, 0)
+	for _, jid := range jids {
+		u, found := userInfo[jid]
+		if !found || len(u.Devices) == 0 {
+			notFound = append(notFound, jid.String())
+			continue
+		}
+		info := models.ContactInfo{
+			JID:          jid.String(),
+			Phone:        jid.User,
+			Status:       u.Status,
+			IsOnWhatsApp: true,
+			IsBusiness:   u.VerifiedName != nil,
+		}
+		if contact, err := instance.Client.Store.Contacts.GetContact(jid); err == nil && contact.Found {
+			info.Name = contact.FullName
+			info.PushName = contact.PushName
+		}
+		contacts[jid.String()] = info
+	}
+
+	data := gin.H{
+		"contacts":  contacts,
+		"not_found": notFound,
+	}
+	if len(invalid) > 0 {
+		data["invalid"] = invalid
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": data})
+}
+
+// GetBusinessHours returns the structured business profile of a contact:
+// operating hours per weekday, categories and contact details. The
+// pinned whatsmeow version has no catalog fetch query, so the catalog
+// listing is reported as unavailable rather than silently empty.
+func (cc *ContactController) GetBusinessHours(c *gin.Context) {
+	instance, ok := cc.getConnectedInstance(c, c.Param("instanceId"))
+	if !ok {
+		return
+	}
+	jid := middleware.JIDFromContext(c)
+
+	profile, err := instance.Client.GetBusinessProfile(jid)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "no business profile; the contact is not a business account"})
+		return
+	}
+
+	hours := make([]gin.H, 0, len(profile.BusinessHours))
+	for _, h := range profile.BusinessHours {
+		entry := gin.H{
+			"day":  h.DayOfWeek,
+			"mode": h.Mode,
+		}
+		if h.OpenTime != "" {
+			entry["open"] = minutesToClock(h.OpenTime)
+		}
+		if h.CloseTime != "" {
+			entry["close"] = minutesToClock(h.CloseTime)
+		}
+		hours = append(hours, entry)
+	}
+	categories := make([]gin.H, 0, len(profile.Categories))
+	for _, cat := range profile.Categories {
+		categories = append(categories, gin.H{"id": cat.ID, "name": cat.Name})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{
+		"jid":        profile.JID.String(),
+		"address":    profile.Address,
+		"email":      profile.Email,
+		"categories": categories,
+		"timezone":   profile.BusinessHoursTimeZone,
+		"hours":      hours,
+		"catalog": gin.H{
+			"available": false,
+			"note":      "catalog listing is not exposed by the current WhatsApp client version",
+		},
+	}})
+}
+
+// minutesToClock renders a minutes-since-midnight value as HH:MM,
+// passing non-numeric values through untouched.
+func minutesToClock(minutes string) string {
+	value, err := strconv.Atoi(minutes)
+	if err != nil || value < 0 {
+		return minutes
+	}
+	return fmt.Sprintf("%02d:%02d", value/60%24, value%60)
+}
+
+// avatarFetchDelay spaces out GetProfilePictureInfo calls so bulk
+// requests do not hammer the picture servers.
+const avatarFetchDelay = 150 * time.Millisecond
+
+// BatchAvatars fetches the preview profile picture URL for many JIDs.
+// Contacts without a picture, or who hide it, come back with a null URL;
+// other failures are reported per JID instead of failing the request.
+func (cc *ContactController) BatchAvatars(c *gin.Context) {
+	var req struct {
+		JIDs []string `json:"jids" binding:"required,min=1"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	instance, ok := cc.getConnectedInstance(c, c.Param("instanceId"))
+	if !ok {
+		return
+	}
+
+	avatars := make([]gin.H, 0, len(req.JIDs))
+	for i, raw := range req.JIDs {
+		var jid types.JID
+		if strings.ContainsRune(raw, '@') {
+			parsed, err := types.ParseJID(raw)
+			if err != nil || parsed.User == "" {
+				avatars = append(avatars, gin.H{"jid": raw, "error": "invalid JID"})
+				continue
+			}
+			jid = parsed
+		} else if phone := utils.NormalizePhone(raw); phone != "" {
+			jid = utils.UserJID(phone)
+		} else {
+			avatars = append(avatars, gin.H{"jid": raw, "error": "invalid JID"})
+			continue
+		}
+
+		if i > 0 {
+			time.Sleep(avatarFetchDelay)
+		}
+		pic, err := instance.Client.GetProfilePictureInfo(jid, &whatsmeow.GetProfilePictureParams{Preview: true})
+		switch {
+		case errors.Is(err, whatsmeow.ErrProfilePictureNotSet), errors.Is(err, whatsmeow.ErrProfilePictureUnauthorized):
+			avatars = append(avatars, gin.H{"jid": jid.String(), "url": nil})
+		case err != nil:
+			avatars = append(avatars, gin.H{"jid": jid.String(), "error": err.Error()})
+		case pic == nil:
+			avatars = append(avatars, gin.H{"jid": jid.String(), "url": nil})
+		default:
+			avatars = append(avatars, gin.H{"jid": jid.String(), "url": pic.URL, "picture_id": pic.ID})
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": avatars})
+}
+
+// formatFingerprint renders a hash as space-separated groups of four hex
+// characters, the way safety numbers are usually displayed.
+func formatFingerprint(sum []byte) string {
+	hexSum := hex.EncodeToString(sum)
+	groups := make([]string, 0, len(hexSum)/4)
+	for i := 0; i+4 <= len(hexSum); i += 4 {
+		groups = append(groups, hexSum[i:i+4])
+	}
+	return strings.Join(groups, " ")
+}
+
+// CheckContacts checks which of the given phone numbers are on WhatsApp.
+// Large inputs are chunked and queried with a delay between chunks; a
+// failing chunk is reported alongside the results of the others instead
+// of failing the whole request.
+func (cc *ContactController) CheckContacts(c *gin.Context) {
+	var req models.CheckContactsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	instance, ok := cc.getConnectedInstance(c, req.InstanceID)
+	if !ok {
+		return
+	}
+	phones := make([]string, 0, len(req.Phones))
+	for _, p := range req.Phones {
+		phones = append(phones, "+"+utils.NormalizePhone(p))
+	}
+
+	cfg := config.Get()
+	chunks := utils.ChunkStrings(phones, cfg.CheckContactsBatchSize)
+	out := make([]gin.H, 0, len(phones))
+	batchErrors := make([]gin.H, 0)
+	for i, chunk := range chunks {
+		if i > 0 && cfg.CheckContactsDelayMs > 0 {
+			time.Sleep(time.Duration(cfg.CheckContactsDelayMs) * time.Millisecond)
+		}
+		results, err := instance.Client.IsOnWhatsApp(chunk)
+		if err != nil {
+			batchErrors = append(batchErrors, gin.H{
+				"batch": i,
+				"size":  len(chunk),
+				"error": err.Error(),
+			})
+			continue
+		}
+		for _, r := range results {
+			out = append(out, gin.H{
+				"query":          r.Query,
+				"jid":            r.JID.String(),
+				"is_on_whatsapp": r.IsIn,
+			})
+		}
+	}
+
+	response := gin.H{"success": len(batchErrors) == 0, "data": out}
+	if len(batchErrors) > 0 {
+		response["batch_errors"] = batchErrors
+	}
+	status := http.StatusOK
+	if len(batchErrors) > 0 && len(out) == 0 {
+		status = http.StatusBadGateway
+	}
+	c.JSON(status, response)
+}
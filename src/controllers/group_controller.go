@@ -0,0 +1,621 @@
+package controllers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+
+	"github.com/Layoxd/whatsapiGo/src/database"
+	"github.com/Layoxd/whatsapiGo/src/middleware"
+	"github.com/Layoxd/whatsapiGo/src/models"
+	"github.com/Layoxd/whatsapiGo/src/utils"
+)
+
+// GroupController handles group management.
+type GroupController struct {
+	instanceController *InstanceController
+}
+
+// NewGroupController creates the group controller.
+func NewGroupController(ic *InstanceController) *GroupController {
+	return &GroupController{instanceController: ic}
+}
+
+// getConnectedInstance mirrors the message controller helper.
+func (gc *GroupController) getConnectedInstance(c *gin.Context, instanceID string) (*Instance, bool) {
+	instance, ok := gc.instanceController.getInstance(instanceID)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "instance not found"})
+		return nil, false
+	}
+	if !instance.Client.IsLoggedIn() {
+		c.JSON(http.StatusConflict, gin.H{"success": false, "error": "instance is not connected"})
+		return nil, false
+	}
+	return instance, true
+}
+
+// mapGroupInfo converts a whatsmeow group into the API structure.
+func mapGroupInfo(info *types.GroupInfo) *models.GroupInfo {
+	group := &models.GroupInfo{
+		JID:              info.JID.String(),
+		Name:             info.Name,
+		Topic:            info.Topic,
+		CreatedAt:        info.GroupCreated,
+		IsAnnounce:       info.IsAnnounce,
+		IsLocked:         info.IsLocked,
+		IsEphemeral:      info.IsEphemeral,
+		ParticipantCount: len(info.Participants),
+	}
+	if !info.OwnerJID.IsEmpty() {
+		group.OwnerJID = info.OwnerJID.ToNonAD().String()
+	}
+	for _, p := range info.Participants {
+		participant := models.GroupParticipantInfo{
+			JID:          p.JID.ToNonAD().String(),
+			Phone:        p.JID.User,
+			IsAdmin:      p.IsAdmin || p.IsSuperAdmin,
+			IsSuperAdmin: p.IsSuperAdmin,
+		}
+		if !p.LID.IsEmpty() {
+			participant.LID = p.LID.ToNonAD().String()
+		}
+		group.Participants = append(group.Participants, participant)
+	}
+	return group
+}
+
+// getDetailedGroupInfo fetches a group and maps it into the API structure.
+func (gc *GroupController) getDetailedGroupInfo(instance *Instance, jid types.JID) (*models.GroupInfo, error) {
+	info, err := instance.Client.GetGroupInfo(jid)
+	if err != nil {
+		return nil, err
+	}
+	return mapGroupInfo(info), nil
+}
+
+// getAllGroups lists the groups the instance participates in.
+func (gc *GroupController) getAllGroups(instance *Instance) ([]*models.GroupInfo, error) {
+	joined, err := instance.Client.GetJoinedGroups()
+	if err != nil {
+		return nil, err
+	}
+	return mapJoinedGroups(joined), nil
+}
+
+// mapJoinedGroups converts the joined-group list into the detailed API
+// structure. The server returns groups in no particular order, so the
+// result is sorted by name (then JID) to keep pagination stable.
+func mapJoinedGroups(joined []*types.GroupInfo) []*models.GroupInfo {
+	groups := make([]*models.GroupInfo, 0, len(joined))
+	for _, info := range joined {
+		groups = append(groups, mapGroupInfo(info))
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Name != groups[j].Name {
+			return groups[i].Name < groups[j].Name
+		}
+		return groups[i].JID < groups[j].JID
+	})
+	return groups
+}
+
+// paginateGroups applies limit/offset to the sorted group list. A zero
+// limit returns everything after the offset.
+func paginateGroups(groups []*models.GroupInfo, limit, offset int) []*models.GroupInfo {
+	if offset >= len(groups) {
+		return []*models.GroupInfo{}
+	}
+	groups = groups[offset:]
+	if limit > 0 && limit < len(groups) {
+		groups = groups[:limit]
+	}
+	return groups
+}
+
+// GetGroups lists all groups for an instance. Users can be in hundreds
+// of groups, so ?limit= and ?offset= paginate the list; without a limit
+// the full list is returned.
+func (gc *GroupController) GetGroups(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "0"))
+	if err != nil || limit < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "limit must be a non-negative integer"})
+		return
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "offset must be a non-negative integer"})
+		return
+	}
+	instance, ok := gc.getConnectedInstance(c, c.Param("instanceId"))
+	if !ok {
+		return
+	}
+	groups, err := gc.getAllGroups(instance)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{
+		"groups": paginateGroups(groups, limit, offset),
+		"total":  len(groups),
+		"limit":  limit,
+		"offset": offset,
+	}})
+}
+
+// GetGroup returns the detail of one group.
+func (gc *GroupController) GetGroup(c *gin.Context) {
+	instance, ok := gc.getConnectedInstance(c, c.Param("instanceId"))
+	if !ok {
+		return
+	}
+	group, err := gc.getDetailedGroupInfo(instance, middleware.JIDFromContext(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": group})
+}
+
+// CreateGroup creates a group and applies the optional initial settings
+// (photo, announce, locked, disappearing timer). Creation failing is an
+// error; a failing setting is not — each post-creation step is its own
+// WhatsApp call, so the response reports them per setting and the group
+// JID is returned regardless.
+func (gc *GroupController) CreateGroup(c *gin.Context) {
+	var req models.CreateGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	if req.EphemeralSeconds != nil && *req.EphemeralSeconds != 0 && !allowedEphemeralSeconds[*req.EphemeralSeconds] {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "ephemeral_seconds must be 0, 86400 (24h), 604800 (7d) or 7776000 (90d)"})
+		return
+	}
+	instance, ok := gc.getConnectedInstance(c, req.InstanceID)
+	if !ok {
+		return
+	}
+	participants := make([]types.JID, 0, len(req.Participants))
+	for _, p := range req.Participants {
+		participants = append(participants, utils.UserJID(p))
+	}
+	info, err := instance.Client.CreateGroup(whatsmeow.ReqCreateGroup{
+		Name:         req.Name,
+		Participants: participants,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	updates := []groupFieldUpdate{}
+	if req.AvatarBase64 != "" {
+		updates = append(updates, groupFieldUpdate{"avatar", func() error {
+			avatar, err := base64.StdEncoding.DecodeString(req.AvatarBase64)
+			if err != nil {
+				return errors.New("invalid base64 avatar")
+			}
+			_, err = instance.Client.SetGroupPhoto(info.JID, avatar)
+			return err
+		}})
+	}
+	if req.Announce != nil {
+		updates = append(updates, groupFieldUpdate{"announce", func() error {
+			return instance.Client.SetGroupAnnounce(info.JID, *req.Announce)
+		}})
+	}
+	if req.Locked != nil {
+		updates = append(updates, groupFieldUpdate{"locked", func() error {
+			return instance.Client.SetGroupLocked(info.JID, *req.Locked)
+		}})
+	}
+	if req.EphemeralSeconds != nil {
+		updates = append(updates, groupFieldUpdate{"ephemeral", func() error {
+			return instance.Client.SetDisappearingTimer(info.JID, time.Duration(*req.EphemeralSeconds)*time.Second)
+		}})
+	}
+
+	data := gin.H{"group": mapGroupInfo(info)}
+	if len(updates) > 0 {
+		results, _ := applyGroupUpdates(updates)
+		data["settings"] = results
+	}
+	c.JSON(http.StatusCreated, gin.H{"success": true, "data": data})
+}
+
+// groupFieldUpdate is one named change to apply to a group.
+type groupFieldUpdate struct {
+	field string
+	apply func() error
+}
+
+// groupFieldResult reports how one field update went.
+type groupFieldResult struct {
+	Field string `json:"field"`
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+// applyGroupUpdates runs every field update even when earlier ones fail,
+// so the caller learns the real per-field state instead of guessing
+// where a partial update stopped.
+func applyGroupUpdates(updates []groupFieldUpdate) ([]groupFieldResult, bool) {
+	results := make([]groupFieldResult, 0, len(updates))
+	allOK := true
+	for _, update := range updates {
+		result := groupFieldResult{Field: update.field, OK: true}
+		if err := update.apply(); err != nil {
+			result.OK = false
+			result.Error = err.Error()
+			allOK = false
+		}
+		results = append(results, result)
+	}
+	return results, allOK
+}
+
+// UpdateGroup updates group name, description and/or photo. The changes
+// are separate WhatsApp calls with no rollback, so the response carries
+// a per-field result instead of one generic error.
+func (gc *GroupController) UpdateGroup(c *gin.Context) {
+	var req models.UpdateGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	instance, ok := gc.getConnectedInstance(c, c.Param("instanceId"))
+	if !ok {
+		return
+	}
+	jid := middleware.JIDFromContext(c)
+
+	updates := make([]groupFieldUpdate, 0, 3)
+	if req.Name != nil {
+		updates = append(updates, groupFieldUpdate{"name", func() error {
+			return instance.Client.SetGroupName(jid, *req.Name)
+		}})
+	}
+	if req.Description != nil {
+		updates = append(updates, groupFieldUpdate{"description", func() error {
+			return instance.Client.SetGroupTopic(jid, "", "", *req.Description)
+		}})
+	}
+	if req.AvatarBase64 != nil {
+		avatar, err := base64.StdEncoding.DecodeString(*req.AvatarBase64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "invalid base64 avatar"})
+			return
+		}
+		updates = append(updates, groupFieldUpdate{"avatar", func() error {
+			_, err := instance.Client.SetGroupPhoto(jid, avatar)
+			return err
+		}})
+	}
+	if len(updates) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "no fields to update"})
+		return
+	}
+
+	results, allOK := applyGroupUpdates(updates)
+	status := http.StatusOK
+	if !allOK {
+		status = http.StatusMultiStatus
+	}
+	data := gin.H{"results": results}
+	if group, err := gc.getDetailedGroupInfo(instance, jid); err == nil {
+		data["group"] = group
+	}
+	c.JSON(status, gin.H{"success": allOK, "data": data})
+}
+
+// participantResult is the per-participant outcome of a participant
+// operation; Status carries the HTTP-like code WhatsApp returns for
+// failures.
+type participantResult struct {
+	JID     string `json:"jid"`
+	Success bool   `json:"success"`
+	Status  int    `json:"status,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// participantErrorText translates the WhatsApp participant error codes
+// into readable messages.
+func participantErrorText(code int) string {
+	switch code {
+	case 403:
+		return "not allowed; the user's privacy settings block being added"
+	case 408:
+		return "the user left the group recently and cannot be re-added yet"
+	case 409:
+		return "the user is already in the group"
+	default:
+		return fmt.Sprintf("rejected with code %d", code)
+	}
+}
+
+// participantResults maps the whatsmeow response onto structured
+// per-participant results; the second return is whether all succeeded.
+func participantResults(updated []types.GroupParticipant) ([]participantResult, bool) {
+	results := make([]participantResult, 0, len(updated))
+	allOK := true
+	for _, p := range updated {
+		result := participantResult{JID: p.JID.ToNonAD().String(), Success: p.Error == 0}
+		if p.Error != 0 {
+			result.Status = p.Error
+			result.Error = participantErrorText(p.Error)
+			allOK = false
+		}
+		results = append(results, result)
+	}
+	return results, allOK
+}
+
+// updateParticipants applies one participant change action to a group.
+// WhatsApp can accept some participants and reject others in the same
+// call, so the response carries a per-participant result next to the
+// group summary.
+func (gc *GroupController) updateParticipants(c *gin.Context, action whatsmeow.ParticipantChange) {
+	var req models.ParticipantsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	instance, ok := gc.getConnectedInstance(c, c.Param("instanceId"))
+	if !ok {
+		return
+	}
+	jid := middleware.JIDFromContext(c)
+	participants := make([]types.JID, 0, len(req.Participants))
+	for _, p := range req.Participants {
+		participants = append(participants, utils.UserJID(p))
+	}
+	updated, err := instance.Client.UpdateGroupParticipants(jid, participants, action)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	results, allOK := participantResults(updated)
+	succeeded := 0
+	for _, r := range results {
+		if r.Success {
+			succeeded++
+		}
+	}
+
+	status := http.StatusOK
+	if !allOK {
+		status = http.StatusMultiStatus
+	}
+	data := gin.H{"results": results}
+	if group, err := gc.getDetailedGroupInfo(instance, jid); err == nil {
+		data["group"] = group
+	}
+	c.JSON(status, gin.H{
+		"success": allOK,
+		"message": fmt.Sprintf("%s applied to %d of %d participants", action, succeeded, len(results)),
+		"data":    data,
+	})
+}
+
+// AddParticipants adds members to a group.
+func (gc *GroupController) AddParticipants(c *gin.Context) {
+	gc.updateParticipants(c, whatsmeow.ParticipantChangeAdd)
+}
+
+// RemoveParticipants removes members from a group.
+func (gc *GroupController) RemoveParticipants(c *gin.Context) {
+	gc.updateParticipants(c, whatsmeow.ParticipantChangeRemove)
+}
+
+// PromoteParticipants promotes members to admin.
+func (gc *GroupController) PromoteParticipants(c *gin.Context) {
+	gc.updateParticipants(c, whatsmeow.ParticipantChangePromote)
+}
+
+// DemoteParticipants demotes admins to members.
+func (gc *GroupController) DemoteParticipants(c *gin.Context) {
+	gc.updateParticipants(c, whatsmeow.ParticipantChangeDemote)
+}
+
+// GetInviteLink returns the group invite link, optionally resetting it.
+func (gc *GroupController) GetInviteLink(c *gin.Context) {
+	instance, ok := gc.getConnectedInstance(c, c.Param("instanceId"))
+	if !ok {
+		return
+	}
+	reset := c.Query("reset") == "true"
+	link, err := instance.Client.GetGroupInviteLink(middleware.JIDFromContext(c), reset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{"invite_link": link, "reset": reset}})
+}
+
+// GetInviteLinkInfo returns the current invite link plus the last observed
+// reset: when it happened and who rotated the link. Reset metadata is only
+// available for rotations seen while the instance was connected.
+func (gc *GroupController) GetInviteLinkInfo(c *gin.Context) {
+	instance, ok := gc.getConnectedInstance(c, c.Param("instanceId"))
+	if !ok {
+		return
+	}
+	groupJID := middleware.JIDFromContext(c)
+	link, err := instance.Client.GetGroupInviteLink(groupJID, false)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	data := gin.H{
+		"group_jid":   groupJID.String(),
+		"invite_link": link,
+	}
+	var reset models.GroupInviteReset
+	err = database.DB.
+		Where("instance_id = ? AND group_jid = ?", instance.ID, groupJID.String()).
+		Order("timestamp DESC").
+		First(&reset).Error
+	if err == nil {
+		data["last_reset_at"] = reset.Timestamp
+		if reset.ResetBy != "" {
+			data["last_reset_by"] = reset.ResetBy
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": data})
+}
+
+// JoinGroup joins a group using an invite code or link.
+func (gc *GroupController) JoinGroup(c *gin.Context) {
+	var req struct {
+		InstanceID string `json:"instance_id" binding:"required"`
+		Code       string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	instance, ok := gc.getConnectedInstance(c, req.InstanceID)
+	if !ok {
+		return
+	}
+	jid, err := instance.Client.JoinGroupWithLink(req.Code)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{"group_jid": jid.String()}})
+}
+
+// LeaveGroup leaves a group.
+func (gc *GroupController) LeaveGroup(c *gin.Context) {
+	instance, ok := gc.getConnectedInstance(c, c.Param("instanceId"))
+	if !ok {
+		return
+	}
+	if err := instance.Client.LeaveGroup(middleware.JIDFromContext(c)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "left group"})
+}
+
+// GetGroupChangelog returns the chronological audit trail of a group —
+// participant adds/removes/promotes/demotes and metadata changes — built
+// from the stored group.update and group.participants events.
+func (gc *GroupController) GetGroupChangelog(c *gin.Context) {
+	if _, ok := gc.instanceController.getInstance(c.Param("instanceId")); !ok {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "instance not found"})
+		return
+	}
+	group := middleware.JIDFromContext(c)
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if limit <= 0 || limit > 500 {
+		limit = 50
+	}
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if offset < 0 {
+		offset = 0
+	}
+
+	// The group JID lives inside the JSON payload; the LIKE filter keeps
+	// the query on the indexed instance/event columns first.
+	var entries []models.EventLog
+	err := database.DB.
+		Where("instance_id = ? AND event_type IN ?", c.Param("instanceId"), []string{"group.update", "group.participants"}).
+		Where("payload LIKE ?", `%"group":"`+group.String()+`"%`).
+		Order("created_at ASC").
+		Limit(limit).
+		Offset(offset).
+		Find(&entries).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	changes := make([]gin.H, 0, len(entries))
+	for _, entry := range entries {
+		var details map[string]interface{}
+		if err := json.Unmarshal([]byte(entry.Payload), &details); err != nil {
+			continue
+		}
+		delete(details, "instance_id")
+		changes = append(changes, gin.H{
+			"event_type":  entry.EventType,
+			"recorded_at": entry.CreatedAt,
+			"details":     details,
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{
+		"group":   group.String(),
+		"limit":   limit,
+		"offset":  offset,
+		"changes": changes,
+	}})
+}
+
+// sameGroupIdentity reports whether a participant entry refers to the
+// given user, matching the phone JID or the LID alias so LID-addressed
+// groups resolve correctly.
+func sameGroupIdentity(p types.GroupParticipant, self types.JID) bool {
+	return p.JID.User == self.User || (!p.LID.IsEmpty() && p.LID.User == self.User)
+}
+
+// GetMyRole reports just whether the logged-in user is member, admin or
+// owner of a group, so callers can gate admin operations without pulling
+// the full participant list.
+func (gc *GroupController) GetMyRole(c *gin.Context) {
+	instance, ok := gc.getConnectedInstance(c, c.Param("instanceId"))
+	if !ok {
+		return
+	}
+	if instance.Client.Store.ID == nil {
+		c.JSON(http.StatusConflict, gin.H{"success": false, "error": "instance has no paired account"})
+		return
+	}
+	jid := middleware.JIDFromContext(c)
+	info, err := instance.Client.GetGroupInfo(jid)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	self := instance.Client.Store.ID.ToNonAD()
+
+	role := ""
+	for _, p := range info.Participants {
+		if !sameGroupIdentity(p, self) {
+			continue
+		}
+		switch {
+		case p.IsSuperAdmin || info.OwnerJID.User == self.User:
+			role = "owner"
+		case p.IsAdmin:
+			role = "admin"
+		default:
+			role = "member"
+		}
+		break
+	}
+	if role == "" {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "the instance is not a member of this group"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{
+		"group":    jid.String(),
+		"jid":      self.String(),
+		"role":     role,
+		"is_admin": role == "admin" || role == "owner",
+	}})
+}
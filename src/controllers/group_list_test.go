@@ -0,0 +1,107 @@
+package controllers
+
+import (
+	"testing"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+// fakeJoinedGroups stands in for a GetJoinedGroups response; the mapping
+// and pagination are pure functions over it.
+func fakeJoinedGroups() []*types.GroupInfo {
+	owner := types.NewJID("5511999999999", types.DefaultUserServer)
+	admin := types.NewJID("5511888888888", types.DefaultUserServer)
+	member := types.NewJID("5511777777777", types.DefaultUserServer)
+	memberLID := types.NewJID("98765432101234", types.HiddenUserServer)
+	return []*types.GroupInfo{
+		{
+			JID:       types.NewJID("222-222", types.GroupServer),
+			OwnerJID:  owner,
+			GroupName: types.GroupName{Name: "Beta"},
+			Participants: []types.GroupParticipant{
+				{JID: owner, IsSuperAdmin: true},
+				{JID: member, LID: memberLID},
+			},
+		},
+		{
+			JID:       types.NewJID("111-111", types.GroupServer),
+			OwnerJID:  owner,
+			GroupName: types.GroupName{Name: "Alpha"},
+			Participants: []types.GroupParticipant{
+				{JID: owner, IsSuperAdmin: true},
+				{JID: admin, IsAdmin: true},
+				{JID: member},
+			},
+		},
+		{
+			JID:       types.NewJID("333-333", types.GroupServer),
+			GroupName: types.GroupName{Name: "Gamma"},
+		},
+	}
+}
+
+func TestMapJoinedGroups(t *testing.T) {
+	groups := mapJoinedGroups(fakeJoinedGroups())
+	if len(groups) != 3 {
+		t.Fatalf("mapped %d groups, want 3", len(groups))
+	}
+	if groups[0].Name != "Alpha" || groups[1].Name != "Beta" || groups[2].Name != "Gamma" {
+		t.Fatalf("groups not sorted by name: %s, %s, %s", groups[0].Name, groups[1].Name, groups[2].Name)
+	}
+
+	alpha := groups[0]
+	if alpha.OwnerJID != "5511999999999@s.whatsapp.net" {
+		t.Errorf("owner JID = %q, want the group creator", alpha.OwnerJID)
+	}
+	if alpha.ParticipantCount != 3 || len(alpha.Participants) != 3 {
+		t.Fatalf("Alpha has %d participants, want 3", len(alpha.Participants))
+	}
+	byJID := map[string]bool{}
+	for _, p := range alpha.Participants {
+		byJID[p.JID] = true
+		switch p.JID {
+		case "5511999999999@s.whatsapp.net":
+			if !p.IsAdmin || !p.IsSuperAdmin {
+				t.Error("the owner should be both admin and super admin")
+			}
+		case "5511888888888@s.whatsapp.net":
+			if !p.IsAdmin || p.IsSuperAdmin {
+				t.Error("the admin should be admin but not super admin")
+			}
+		case "5511777777777@s.whatsapp.net":
+			if p.IsAdmin {
+				t.Error("a plain member should not be admin")
+			}
+		}
+	}
+	if len(byJID) != 3 {
+		t.Errorf("unexpected participant JIDs: %v", byJID)
+	}
+
+	beta := groups[1]
+	if beta.Participants[1].LID != "98765432101234@lid" {
+		t.Errorf("participant LID = %q, want the hidden-user JID", beta.Participants[1].LID)
+	}
+	if groups[2].OwnerJID != "" {
+		t.Errorf("Gamma has no owner, got %q", groups[2].OwnerJID)
+	}
+}
+
+func TestPaginateGroups(t *testing.T) {
+	groups := mapJoinedGroups(fakeJoinedGroups())
+
+	page := paginateGroups(groups, 2, 0)
+	if len(page) != 2 || page[0].Name != "Alpha" || page[1].Name != "Beta" {
+		t.Errorf("first page = %d groups starting at %q, want Alpha and Beta", len(page), page[0].Name)
+	}
+	page = paginateGroups(groups, 2, 2)
+	if len(page) != 1 || page[0].Name != "Gamma" {
+		t.Fatalf("second page should hold only Gamma, got %d groups", len(page))
+	}
+	if page = paginateGroups(groups, 2, 5); len(page) != 0 {
+		t.Errorf("offset past the end should return an empty page, got %d groups", len(page))
+	}
+	if page = paginateGroups(groups, 0, 0); len(page) != 3 {
+		t.Errorf("zero limit should return everything, got %d groups", len(page))
+	}
+}
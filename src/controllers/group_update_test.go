@@ -0,0 +1,70 @@
+package controllers
+
+import (
+	"errors"
+	"testing"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+func TestApplyGroupUpdatesAllSucceed(t *testing.T) {
+	applied := make([]string, 0)
+	results, allOK := applyGroupUpdates([]groupFieldUpdate{
+		{"name", func() error { applied = append(applied, "name"); return nil }},
+		{"description", func() error { applied = append(applied, "description"); return nil }},
+	})
+	if !allOK {
+		t.Error("expected allOK for succeeding updates")
+	}
+	if len(applied) != 2 {
+		t.Errorf("applied %v, want both fields", applied)
+	}
+	for _, r := range results {
+		if !r.OK || r.Error != "" {
+			t.Errorf("result %+v, want ok with no error", r)
+		}
+	}
+}
+
+func TestApplyGroupUpdatesPartialFailure(t *testing.T) {
+	applied := make([]string, 0)
+	results, allOK := applyGroupUpdates([]groupFieldUpdate{
+		{"name", func() error { applied = append(applied, "name"); return nil }},
+		{"description", func() error { return errors.New("not admin") }},
+		{"avatar", func() error { applied = append(applied, "avatar"); return nil }},
+	})
+	if allOK {
+		t.Error("expected allOK=false on a failing update")
+	}
+	// Later fields must still be attempted so the caller learns the real
+	// state of each one.
+	if len(applied) != 2 {
+		t.Errorf("applied %v, want name and avatar despite the failure", applied)
+	}
+	if results[0].OK != true || results[1].OK != false || results[2].OK != true {
+		t.Errorf("results %+v, want only description failed", results)
+	}
+	if results[1].Error != "not admin" {
+		t.Errorf("failed result error = %q, want the setter error", results[1].Error)
+	}
+}
+
+func TestParticipantResultsPartialFailure(t *testing.T) {
+	results, allOK := participantResults([]types.GroupParticipant{
+		{JID: types.NewJID("111", types.DefaultUserServer)},
+		{JID: types.NewJID("222", types.DefaultUserServer), Error: 403},
+		{JID: types.NewJID("333", types.DefaultUserServer), Error: 409},
+	})
+	if allOK {
+		t.Error("expected allOK=false with rejected participants")
+	}
+	if !results[0].Success || results[0].Status != 0 || results[0].Error != "" {
+		t.Errorf("result %+v, want clean success", results[0])
+	}
+	if results[1].Success || results[1].Status != 403 || results[1].Error == "" {
+		t.Errorf("result %+v, want 403 failure with message", results[1])
+	}
+	if results[2].Status != 409 {
+		t.Errorf("result %+v, want the WhatsApp code passed through", results[2])
+	}
+}
@@ -0,0 +1,2350 @@
+package controllers
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	qrcode "github.com/skip2/go-qrcode"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/appstate"
+	"go.mau.fi/whatsmeow/proto/waCompanionReg"
+	"go.mau.fi/whatsmeow/store"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	waLog "go.mau.fi/whatsmeow/util/log"
+	"google.golang.org/protobuf/proto"
+	"gorm.io/gorm"
+
+	"github.com/Layoxd/whatsapiGo/src/config"
+	"github.com/Layoxd/whatsapiGo/src/database"
+	"github.com/Layoxd/whatsapiGo/src/models"
+	"github.com/Layoxd/whatsapiGo/src/services"
+	"github.com/Layoxd/whatsapiGo/src/utils"
+)
+
+// Instance is a live WhatsApp instance: the whatsmeow client plus the
+// runtime state the API exposes.
+type Instance struct {
+	ID       string
+	Metadata string
+
+	// Client is fixed for the lifetime of the struct: handlers
+	// dereference it without a lock, so a session reset swaps a whole
+	// replacement Instance into the map instead of mutating the pointer.
+	Client *whatsmeow.Client
+
+	// stateMu guards the mutable runtime fields below — the name, status,
+	// QR code, connect time, the settings toggles, keepaliveStop and the
+	// last session error. The event worker and the QR consumer goroutine
+	// write them while HTTP handlers read and update them concurrently.
+	stateMu     sync.Mutex
+	Name        string
+	Status      string
+	QRCode      string
+	ConnectedAt time.Time
+
+	// events buffers whatsmeow events so the client's event loop never
+	// waits on handlers; a per-instance worker drains it. eventsDone stops
+	// the worker on delete or reset — the events channel itself is never
+	// closed, because whatsmeow handler goroutines may still be about to
+	// send on it (see stopEventWorker). eventHandlerID identifies the
+	// registered whatsmeow handler so it can be removed again.
+	events         chan interface{}
+	eventsDone     chan struct{}
+	eventsStopped  chan struct{}
+	eventHandlerID uint32
+	droppedEvents  atomic.Uint64
+
+	// presenceKeepalive mirrors the persisted setting; keepaliveStop is
+	// non-nil while the keepalive goroutine is running.
+	presenceKeepalive bool
+	keepaliveStop     chan struct{}
+
+	// autoRead mirrors the persisted setting: incoming messages are
+	// marked read by the event worker as they arrive.
+	autoRead bool
+
+	// autoDownloadMedia mirrors the persisted setting: incoming media is
+	// fetched and stored to disk by the event worker.
+	autoDownloadMedia bool
+
+	// osName and platform form the device fingerprint presented to
+	// WhatsApp at pairing; empty means whatsmeow's defaults. Both are set
+	// at construction and never change afterwards.
+	osName   string
+	platform string
+
+	// lastError holds the most recent session-level error (stream error,
+	// temporary ban, outdated client) so operators can tell a ban from an
+	// ordinary disconnect.
+	lastError   string
+	lastErrorAt time.Time
+
+	// eventCounts tallies events by kind since the last connect, for the
+	// event-counts endpoint. Guarded by eventCountsMu because the event
+	// worker writes while HTTP handlers read.
+	eventCountsMu sync.Mutex
+	eventCounts   map[string]uint64
+
+	// deliveryWaiters holds one channel per outgoing message a send
+	// handler is synchronously waiting on; the channel is closed when the
+	// delivered receipt arrives.
+	deliveryWaitersMu sync.Mutex
+	deliveryWaiters   map[string]chan struct{}
+
+	// Initial-sync tracking for the sync-progress endpoint: which
+	// app-state collections have completed and how the offline event
+	// catch-up is going. Guarded by syncMu because the event worker
+	// writes while HTTP handlers read.
+	syncMu        sync.Mutex
+	syncedPatches map[string]bool
+	offlineTotal  int
+	offlineDone   bool
+}
+
+// registerDeliveryWaiter creates the wait channel for a message ID. It
+// must be called before the send so a fast receipt cannot be missed.
+func (i *Instance) registerDeliveryWaiter(messageID string) chan struct{} {
+	waiter := make(chan struct{})
+	i.deliveryWaitersMu.Lock()
+	if i.deliveryWaiters == nil {
+		i.deliveryWaiters = make(map[string]chan struct{})
+	}
+	i.deliveryWaiters[messageID] = waiter
+	i.deliveryWaitersMu.Unlock()
+	return waiter
+}
+
+// removeDeliveryWaiter drops the wait channel once the caller stops
+// waiting.
+func (i *Instance) removeDeliveryWaiter(messageID string) {
+	i.deliveryWaitersMu.Lock()
+	delete(i.deliveryWaiters, messageID)
+	i.deliveryWaitersMu.Unlock()
+}
+
+// signalDelivery wakes the waiter for a delivered message, if any.
+func (i *Instance) signalDelivery(messageID string) {
+	i.deliveryWaitersMu.Lock()
+	if waiter, ok := i.deliveryWaiters[messageID]; ok {
+		close(waiter)
+		delete(i.deliveryWaiters, messageID)
+	}
+	i.deliveryWaitersMu.Unlock()
+}
+
+// InstanceController manages the lifecycle of WhatsApp instances and owns
+// the in-memory instances map the other controllers read from.
+type InstanceController struct {
+	// instancesMu guards instances: gin handlers, whatsmeow event
+	// callbacks and the webhook service all touch the map concurrently.
+	instancesMu sync.RWMutex
+	instances   map[string]*Instance
+
+	webhookService *services.WebhookService
+
+	// callController handles incoming call events; assigned during route
+	// setup to avoid a construction cycle.
+	callController *CallController
+
+	// deviceUsageMu guards deviceUsage, the short-lived cache of linked
+	// device counts per instance.
+	deviceUsageMu sync.Mutex
+	deviceUsage   map[string]deviceUsage
+}
+
+// deviceUsage is one cached linked-device count.
+type deviceUsage struct {
+	linked    int
+	total     int
+	fetchedAt time.Time
+}
+
+// NewInstanceController creates the controller with an empty instance map.
+func NewInstanceController(webhookService *services.WebhookService) *InstanceController {
+	return &InstanceController{
+		instances:      make(map[string]*Instance),
+		webhookService: webhookService,
+		deviceUsage:    make(map[string]deviceUsage),
+	}
+}
+
+// SetCallController wires the call controller in after construction; the
+// two controllers reference each other.
+func (ic *InstanceController) SetCallController(cc *CallController) {
+	ic.callController = cc
+}
+
+// getInstance looks up a live instance by ID. It is the thread-safe
+// accessor the other controllers go through.
+func (ic *InstanceController) getInstance(id string) (*Instance, bool) {
+	ic.instancesMu.RLock()
+	defer ic.instancesMu.RUnlock()
+	instance, ok := ic.instances[id]
+	return instance, ok
+}
+
+// instanceCount returns how many live instances are registered.
+func (ic *InstanceController) instanceCount() int {
+	ic.instancesMu.RLock()
+	defer ic.instancesMu.RUnlock()
+	return len(ic.instances)
+}
+
+// snapshotInstances copies the instances map so callers can iterate
+// without holding the lock across whatsmeow or database calls.
+func (ic *InstanceController) snapshotInstances() map[string]*Instance {
+	ic.instancesMu.RLock()
+	defer ic.instancesMu.RUnlock()
+	snapshot := make(map[string]*Instance, len(ic.instances))
+	for id, instance := range ic.instances {
+		snapshot[id] = instance
+	}
+	return snapshot
+}
+
+// addInstance registers a live instance.
+func (ic *InstanceController) addInstance(instance *Instance) {
+	ic.instancesMu.Lock()
+	defer ic.instancesMu.Unlock()
+	ic.instances[instance.ID] = instance
+}
+
+// removeInstance drops a live instance from the map.
+func (ic *InstanceController) removeInstance(id string) {
+	ic.instancesMu.Lock()
+	defer ic.instancesMu.Unlock()
+	delete(ic.instances, id)
+}
+
+// CreateInstance creates a new instance with a fresh whatsmeow device.
+func (ic *InstanceController) CreateInstance(c *gin.Context) {
+	var req models.CreateInstanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	if limit, count := config.Get().MaxInstances, ic.instanceCount(); limit > 0 && count >= limit {
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"success": false,
+			"error":   fmt.Sprintf("instance limit reached (%d/%d); delete an instance or raise MAX_INSTANCES", count, limit),
+			"current": count,
+			"limit":   limit,
+		})
+		return
+	}
+
+	platform := strings.ToLower(req.Platform)
+	if platform != "" {
+		if _, ok := platformTypes[platform]; !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "platform must be one of: " + strings.Join(platformNames(), ", ")})
+			return
+		}
+	}
+
+	record, err := ic.createInstance(req.Name, "", req.OSName, platform)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"success": true, "data": record})
+}
+
+// createInstance registers a fresh whatsmeow device as a live instance
+// and persists its record. The caller checks the instance limit first.
+func (ic *InstanceController) createInstance(name, metadata, osName, platform string) (models.Instance, error) {
+	id := uuid.NewString()
+	device := database.Container.NewDevice()
+	client := whatsmeow.NewClient(device, waLog.Stdout("Client/"+id[:8], config.Get().LogLevel, true))
+	client.AutomaticMessageRerequestFromPhone = config.Get().RerequestFromPhone
+
+	instance := &Instance{
+		ID:       id,
+		Name:     name,
+		Metadata: metadata,
+		Client:   client,
+		Status:   models.InstanceStatusCreated,
+		osName:   osName,
+		platform: platform,
+	}
+	ic.setupEventHandlers(instance)
+	ic.addInstance(instance)
+
+	record := models.Instance{
+		ID:       id,
+		Name:     name,
+		Status:   models.InstanceStatusCreated,
+		Metadata: metadata,
+		OSName:   osName,
+		Platform: platform,
+	}
+	if err := database.DB.Create(&record).Error; err != nil {
+		ic.removeInstance(id)
+		return models.Instance{}, err
+	}
+	return record, nil
+}
+
+// BatchCreateInstances provisions several instances in one request. Items
+// are processed in order; once the instance limit is hit the rest are
+// reported as skipped. A 207 response means some items failed or were
+// skipped.
+func (ic *InstanceController) BatchCreateInstances(c *gin.Context) {
+	var req models.BatchCreateInstancesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	limit := config.Get().MaxInstances
+	results := make([]gin.H, 0, len(req.Instances))
+	created := 0
+	for _, item := range req.Instances {
+		result := gin.H{"name": item.Name, "success": false}
+		if count := ic.instanceCount(); limit > 0 && count >= limit {
+			result["error"] = fmt.Sprintf("instance limit reached (%d/%d)", count, limit)
+			result["skipped"] = true
+			results = append(results, result)
+			continue
+		}
+		metadata := ""
+		if item.Metadata != nil {
+			encoded, err := json.Marshal(item.Metadata)
+			if err != nil {
+				result["error"] = "encoding metadata: " + err.Error()
+				results = append(results, result)
+				continue
+			}
+			metadata = string(encoded)
+		}
+		record, err := ic.createInstance(item.Name, metadata, "", "")
+		if err != nil {
+			result["error"] = err.Error()
+			results = append(results, result)
+			continue
+		}
+		created++
+		result["success"] = true
+		result["instance_id"] = record.ID
+		if item.Webhook != nil {
+			if err := createInstanceWebhook(record.ID, item.Webhook); err != nil {
+				result["webhook_error"] = err.Error()
+			}
+		}
+		results = append(results, result)
+	}
+
+	status := http.StatusCreated
+	if created < len(req.Instances) {
+		status = http.StatusMultiStatus
+	}
+	c.JSON(status, gin.H{"success": created > 0, "data": gin.H{
+		"created": created,
+		"total":   len(req.Instances),
+		"results": results,
+	}})
+}
+
+// GetResourceStats reports the process's resource footprint for capacity
+// planning: process-wide memory and goroutine numbers plus a per-instance
+// breakdown. Per-instance memory cannot be measured directly in Go, so
+// the heap share is the total divided across instances — an estimate,
+// labelled as such. Goroutines attributed to instances are the event
+// workers and running keepalive loops.
+func (ic *InstanceController) GetResourceStats(c *gin.Context) {
+	instances := ic.snapshotInstances()
+
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	connected := 0
+	keepalives := 0
+	perInstance := make([]gin.H, 0, len(instances))
+	for _, instance := range instances {
+		isConnected := instance.Client.IsConnected()
+		if isConnected {
+			connected++
+		}
+		instance.stateMu.Lock()
+		name := instance.Name
+		status := instance.Status
+		keepaliveRunning := instance.keepaliveStop != nil
+		instance.stateMu.Unlock()
+		if keepaliveRunning {
+			keepalives++
+		}
+		perInstance = append(perInstance, gin.H{
+			"id":                    instance.ID,
+			"name":                  name,
+			"status":                status,
+			"websocket_active":      isConnected,
+			"queued_events":         len(instance.events),
+			"event_buffer_capacity": cap(instance.events),
+			"dropped_events":        instance.droppedEvents.Load(),
+			"keepalive_running":     keepaliveRunning,
+		})
+	}
+	sort.Slice(perInstance, func(i, j int) bool {
+		return perInstance[i]["id"].(string) < perInstance[j]["id"].(string)
+	})
+
+	estimatedShare := uint64(0)
+	if len(instances) > 0 {
+		estimatedShare = mem.HeapAlloc / uint64(len(instances))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{
+		"process": gin.H{
+			"goroutines":       runtime.NumGoroutine(),
+			"heap_alloc_bytes": mem.HeapAlloc,
+			"heap_sys_bytes":   mem.HeapSys,
+			"sys_bytes":        mem.Sys,
+			"num_gc":           mem.NumGC,
+		},
+		"instances": gin.H{
+			"total":                len(instances),
+			"active_websockets":    connected,
+			"event_workers":        len(instances),
+			"keepalive_goroutines": keepalives,
+			// Rough split of the heap across instances; Go cannot
+			// attribute allocations to an instance.
+			"estimated_heap_share_bytes": estimatedShare,
+			"detail":                     perInstance,
+		},
+	}})
+}
+
+// RestoreInstances rebuilds the live instances map from persisted rows,
+// so a restart does not orphan paired devices. Rows whose device still
+// exists in the session store get their client back and are reconnected
+// in the background; rows that never paired come back with a fresh
+// device, ready for the QR endpoint again. It returns how many
+// instances were restored.
+func (ic *InstanceController) RestoreInstances() (int, error) {
+	var rows []models.Instance
+	if err := database.DB.Find(&rows).Error; err != nil {
+		return 0, err
+	}
+	restored := 0
+	for _, row := range rows {
+		if _, live := ic.getInstance(row.ID); live {
+			continue
+		}
+		var device *store.Device
+		if row.DeviceJID != "" {
+			jid, err := types.ParseJID(row.DeviceJID)
+			if err == nil {
+				if device, err = database.Container.GetDevice(jid); err != nil {
+					log.Printf("instance %s: loading device %s: %v", row.ID, row.DeviceJID, err)
+					device = nil
+				}
+			}
+		}
+		if device == nil {
+			device = database.Container.NewDevice()
+		}
+		client := whatsmeow.NewClient(device, waLog.Stdout("Client/"+row.ID[:8], config.Get().LogLevel, true))
+		client.AutomaticMessageRerequestFromPhone = config.Get().RerequestFromPhone
+
+		instance := &Instance{
+			ID:                row.ID,
+			Name:              row.Name,
+			Metadata:          row.Metadata,
+			Client:            client,
+			Status:            models.InstanceStatusDisconnected,
+			presenceKeepalive: row.PresenceKeepalive,
+			autoRead:          row.AutoRead,
+			autoDownloadMedia: row.AutoDownloadMedia,
+			osName:            row.OSName,
+			platform:          row.Platform,
+		}
+		if device.ID == nil {
+			instance.Status = models.InstanceStatusCreated
+		}
+		ic.setupEventHandlers(instance)
+		ic.addInstance(instance)
+		ic.persistStatus(instance)
+		restored++
+
+		if device.ID != nil {
+			go func(instance *Instance) {
+				if err := ic.connectWithFingerprint(instance); err != nil {
+					log.Printf("instance %s: reconnect after restart: %v", instance.ID, err)
+				}
+			}(instance)
+		}
+	}
+	return restored, nil
+}
+
+// CheckNumber reports whether a phone number is registered on WhatsApp
+// before an operator burns a pairing attempt on it. whatsmeow has no
+// unauthenticated lookup in this version, so the query is routed through
+// a logged-in instance: the one named in the request, or any connected
+// one. The result is best-effort — it only proves registration, not
+// that the number can pair or is free of a ban.
+func (ic *InstanceController) CheckNumber(c *gin.Context) {
+	var req models.CheckNumberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	var via *Instance
+	if req.InstanceID != "" {
+		instance, ok := ic.getInstance(req.InstanceID)
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "instance not found"})
+			return
+		}
+		via = instance
+	} else {
+		for _, instance := range ic.snapshotInstances() {
+			if instance.Client.IsLoggedIn() {
+				via = instance
+				break
+			}
+		}
+	}
+	if via == nil || !via.Client.IsLoggedIn() {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error":   "no logged-in instance available; the number check requires an existing paired account",
+		})
+		return
+	}
+	results, err := via.Client.IsOnWhatsApp([]string{"+" + utils.NormalizePhone(req.Phone)})
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"success": false, "error": "number check failed: " + err.Error()})
+		return
+	}
+	if len(results) == 0 {
+		c.JSON(http.StatusBadGateway, gin.H{"success": false, "error": "number check returned no result"})
+		return
+	}
+	r := results[0]
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{
+		"phone":          req.Phone,
+		"jid":            r.JID.String(),
+		"is_on_whatsapp": r.IsIn,
+		"checked_via":    via.ID,
+		// Registration is all WhatsApp exposes here; a registered
+		// number can still fail to pair or be banned.
+		"limitations": "registration check only; ban status is not detectable before pairing",
+	}})
+}
+
+// createInstanceWebhook attaches the minimal webhook from a batch item to
+// a freshly created instance, mirroring the validation of the configure
+// endpoint.
+func createInstanceWebhook(instanceID string, cfg *models.BatchWebhookConfig) error {
+	if _, err := url.ParseRequestURI(cfg.URL); err != nil {
+		return errors.New("invalid webhook URL")
+	}
+	subscribed := cfg.Events
+	if len(subscribed) == 0 {
+		subscribed = config.Get().DefaultWebhookEvents
+	}
+	if len(subscribed) == 0 {
+		return errors.New("events is required and no default subscription is configured")
+	}
+	for _, ev := range subscribed {
+		if ev != "*" && !services.KnownEventType(ev) {
+			return errors.New("unknown event type: " + ev)
+		}
+	}
+	events, err := json.Marshal(subscribed)
+	if err != nil {
+		return err
+	}
+	webhook := models.Webhook{
+		ID:         uuid.NewString(),
+		InstanceID: instanceID,
+		URL:        cfg.URL,
+		Secret:     cfg.Secret,
+		Events:     string(events),
+		Active:     true,
+	}
+	return database.DB.Create(&webhook).Error
+}
+
+// GetInstances lists all live instances.
+func (ic *InstanceController) GetInstances(c *gin.Context) {
+	instances := ic.snapshotInstances()
+	list := make([]gin.H, 0, len(instances))
+	for _, instance := range instances {
+		list = append(list, ic.instanceDetail(instance))
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": list})
+}
+
+// GetInstance returns the detail of one instance.
+func (ic *InstanceController) GetInstance(c *gin.Context) {
+	instance, ok := ic.getInstance(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "instance not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": ic.instanceDetail(instance)})
+}
+
+// instanceDetail builds the public representation of an instance.
+func (ic *InstanceController) instanceDetail(instance *Instance) gin.H {
+	instance.stateMu.Lock()
+	detail := gin.H{
+		"id":                  instance.ID,
+		"name":                instance.Name,
+		"status":              instance.Status,
+		"presence_keepalive":  instance.presenceKeepalive,
+		"auto_read":           instance.autoRead,
+		"auto_download_media": instance.autoDownloadMedia,
+	}
+	if !instance.ConnectedAt.IsZero() {
+		detail["connected_at"] = instance.ConnectedAt
+	}
+	if instance.lastError != "" {
+		detail["last_error"] = instance.lastError
+		detail["last_error_at"] = instance.lastErrorAt
+	}
+	instance.stateMu.Unlock()
+
+	detail["connected"] = instance.Client.IsConnected()
+	detail["logged_in"] = instance.Client.IsLoggedIn()
+	if instance.Metadata != "" {
+		detail["metadata"] = json.RawMessage(instance.Metadata)
+	}
+	if instance.osName != "" || instance.platform != "" {
+		detail["fingerprint"] = gin.H{
+			"os_name":  instance.osName,
+			"platform": instance.platform,
+		}
+	}
+	if instance.Client.Store.ID != nil {
+		detail["jid"] = instance.Client.Store.ID.String()
+		detail["phone"] = instance.Client.Store.ID.User
+	}
+	concurrency, timeoutSeconds := ic.webhookService.EffectiveDeliverySettings(instance.ID)
+	detail["webhook_delivery"] = gin.H{
+		"concurrency":     concurrency,
+		"timeout_seconds": timeoutSeconds,
+	}
+	return detail
+}
+
+// UpdateInstance changes instance settings: the name, the presence
+// keepalive toggle and the webhook delivery overrides. The keepalive
+// takes effect immediately when the instance is connected.
+func (ic *InstanceController) UpdateInstance(c *gin.Context) {
+	instance, ok := ic.getInstance(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "instance not found"})
+		return
+	}
+	var req struct {
+		Name              *string `json:"name"`
+		PresenceKeepalive *bool   `json:"presence_keepalive"`
+		AutoRead          *bool   `json:"auto_read"`
+		AutoDownloadMedia *bool   `json:"auto_download_media"`
+		// Zero clears an override back to the global config value.
+		WebhookConcurrency    *int `json:"webhook_concurrency"`
+		WebhookTimeoutSeconds *int `json:"webhook_timeout_seconds"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	if req.WebhookConcurrency != nil && *req.WebhookConcurrency < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "webhook_concurrency must be zero or positive"})
+		return
+	}
+	if req.WebhookTimeoutSeconds != nil && *req.WebhookTimeoutSeconds < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "webhook_timeout_seconds must be zero or positive"})
+		return
+	}
+
+	updates := map[string]interface{}{}
+	instance.stateMu.Lock()
+	if req.Name != nil {
+		instance.Name = *req.Name
+		updates["name"] = *req.Name
+	}
+	if req.PresenceKeepalive != nil {
+		instance.presenceKeepalive = *req.PresenceKeepalive
+		updates["presence_keepalive"] = *req.PresenceKeepalive
+	}
+	if req.AutoRead != nil {
+		instance.autoRead = *req.AutoRead
+		updates["auto_read"] = *req.AutoRead
+	}
+	if req.AutoDownloadMedia != nil {
+		instance.autoDownloadMedia = *req.AutoDownloadMedia
+		updates["auto_download_media"] = *req.AutoDownloadMedia
+	}
+	instance.stateMu.Unlock()
+	if req.PresenceKeepalive != nil {
+		if *req.PresenceKeepalive && instance.Client.IsLoggedIn() {
+			ic.startKeepalive(instance)
+		} else if !*req.PresenceKeepalive {
+			ic.stopKeepalive(instance)
+		}
+	}
+	if req.WebhookConcurrency != nil {
+		updates["webhook_concurrency"] = *req.WebhookConcurrency
+	}
+	if req.WebhookTimeoutSeconds != nil {
+		updates["webhook_timeout_seconds"] = *req.WebhookTimeoutSeconds
+	}
+	if len(updates) > 0 {
+		database.DB.Model(&models.Instance{}).Where("id = ?", instance.ID).Updates(updates)
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": ic.instanceDetail(instance)})
+}
+
+// presenceKeepaliveInterval is how often the keepalive re-sends available
+// presence. WhatsApp drops the online state after roughly five minutes of
+// silence.
+const presenceKeepaliveInterval = 4 * time.Minute
+
+// startKeepalive launches the presence keepalive goroutine if it is not
+// already running.
+func (ic *InstanceController) startKeepalive(instance *Instance) {
+	instance.stateMu.Lock()
+	if instance.keepaliveStop != nil {
+		instance.stateMu.Unlock()
+		return
+	}
+	stop := make(chan struct{})
+	instance.keepaliveStop = stop
+	instance.stateMu.Unlock()
+	if instance.Client.IsLoggedIn() {
+		_ = instance.Client.SendPresence(types.PresenceAvailable)
+	}
+	go func() {
+		ticker := time.NewTicker(presenceKeepaliveInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if instance.Client.IsLoggedIn() {
+					_ = instance.Client.SendPresence(types.PresenceAvailable)
+				}
+			}
+		}
+	}()
+}
+
+// stopKeepalive stops the presence keepalive goroutine if it is running.
+func (ic *InstanceController) stopKeepalive(instance *Instance) {
+	instance.stateMu.Lock()
+	stop := instance.keepaliveStop
+	instance.keepaliveStop = nil
+	instance.stateMu.Unlock()
+	if stop != nil {
+		close(stop)
+	}
+}
+
+// DeleteInstance logs out (unlinking the device) and removes the instance.
+func (ic *InstanceController) DeleteInstance(c *gin.Context) {
+	id := c.Param("id")
+	instance, ok := ic.getInstance(id)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "instance not found"})
+		return
+	}
+	if instance.Client.IsLoggedIn() {
+		// Best effort: unlink the device from the phone.
+		_ = instance.Client.Logout()
+	}
+	instance.Client.Disconnect()
+	ic.stopKeepalive(instance)
+	ic.stopEventWorker(instance)
+	ic.removeInstance(id)
+	database.DB.Where("id = ?", id).Delete(&models.Instance{})
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "instance deleted"})
+}
+
+// GetQRCode starts a pairing flow and returns the QR code to scan, both as
+// the raw code and as a base64 PNG.
+func (ic *InstanceController) GetQRCode(c *gin.Context) {
+	instance, ok := ic.getInstance(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "instance not found"})
+		return
+	}
+	if instance.Client.Store.ID != nil {
+		c.JSON(http.StatusConflict, gin.H{"success": false, "error": "instance is already paired"})
+		return
+	}
+
+	// A QR flow already in progress keeps the latest code cached (see
+	// consumeQRChannel); serve that instead of tearing the connection
+	// down for a new channel every time a frontend polls for the image.
+	instance.stateMu.Lock()
+	cached := instance.QRCode
+	instance.stateMu.Unlock()
+	if cached != "" && instance.Client.IsConnected() {
+		png, err := qrcode.Encode(cached, qrcode.Medium, 256)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{
+			"qr_code": cached,
+			"image":   "data:image/png;base64," + base64.StdEncoding.EncodeToString(png),
+			"cached":  true,
+		}})
+		return
+	}
+
+	if instance.Client.IsConnected() {
+		instance.Client.Disconnect()
+	}
+	qrChan, err := instance.Client.GetQRChannel(context.Background())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	if err := ic.connectWithFingerprint(instance); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	instance.stateMu.Lock()
+	instance.Status = models.InstanceStatusQRPending
+	instance.stateMu.Unlock()
+	ic.persistStatus(instance)
+
+	select {
+	case item := <-qrChan:
+		if item.Event != whatsmeow.QRChannelEventCode {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "pairing failed: " + item.Event})
+			return
+		}
+		instance.stateMu.Lock()
+		instance.QRCode = item.Code
+		instance.stateMu.Unlock()
+		go ic.consumeQRChannel(instance, qrChan)
+		png, err := qrcode.Encode(item.Code, qrcode.Medium, 256)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{
+			"qr_code":   item.Code,
+			"image":     "data:image/png;base64," + base64.StdEncoding.EncodeToString(png),
+			"timeout_s": int(item.Timeout.Seconds()),
+		}})
+	case <-time.After(15 * time.Second):
+		c.JSON(http.StatusGatewayTimeout, gin.H{"success": false, "error": "timed out waiting for QR code"})
+	}
+}
+
+// consumeQRChannel keeps the latest code cached until the channel closes.
+func (ic *InstanceController) consumeQRChannel(instance *Instance, qrChan <-chan whatsmeow.QRChannelItem) {
+	for item := range qrChan {
+		if item.Event == whatsmeow.QRChannelEventCode {
+			instance.stateMu.Lock()
+			instance.QRCode = item.Code
+			instance.stateMu.Unlock()
+		}
+	}
+	instance.stateMu.Lock()
+	instance.QRCode = ""
+	instance.stateMu.Unlock()
+}
+
+// ConnectInstance connects an already-paired instance.
+func (ic *InstanceController) ConnectInstance(c *gin.Context) {
+	instance, ok := ic.getInstance(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "instance not found"})
+		return
+	}
+	if instance.Client.Store.ID == nil {
+		c.JSON(http.StatusConflict, gin.H{"success": false, "error": "instance is not paired; use the QR endpoint first"})
+		return
+	}
+	if instance.Client.IsConnected() {
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": "already connected"})
+		return
+	}
+	if err := ic.connectWithFingerprint(instance); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "connecting"})
+}
+
+// DisconnectInstance closes the socket without unlinking the device.
+func (ic *InstanceController) DisconnectInstance(c *gin.Context) {
+	instance, ok := ic.getInstance(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "instance not found"})
+		return
+	}
+	instance.Client.Disconnect()
+	instance.stateMu.Lock()
+	instance.Status = models.InstanceStatusDisconnected
+	instance.stateMu.Unlock()
+	ic.persistStatus(instance)
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "disconnected"})
+}
+
+// LogoutInstance unlinks the device but keeps the instance record.
+func (ic *InstanceController) LogoutInstance(c *gin.Context) {
+	instance, ok := ic.getInstance(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "instance not found"})
+		return
+	}
+	if err := instance.Client.Logout(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	instance.stateMu.Lock()
+	instance.Status = models.InstanceStatusDisconnected
+	instance.stateMu.Unlock()
+	ic.persistStatus(instance)
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "logged out"})
+}
+
+// ResetInstanceSession discards the whatsmeow session of an instance and
+// binds a fresh device to the same instance ID, so a broken session can be
+// re-paired without losing the instance record, webhooks or history. The
+// caller re-pairs through the usual QR endpoint afterwards.
+func (ic *InstanceController) ResetInstanceSession(c *gin.Context) {
+	instance, ok := ic.getInstance(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "instance not found"})
+		return
+	}
+
+	if instance.Client.IsLoggedIn() {
+		// Best effort: tell the phone the device is going away.
+		_ = instance.Client.Logout()
+	}
+	instance.Client.Disconnect()
+	ic.stopKeepalive(instance)
+	if instance.Client.Store.ID != nil {
+		if err := instance.Client.Store.Delete(); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "clearing session store: " + err.Error()})
+			return
+		}
+	}
+	// Detach the old client's handler and stop its worker; the
+	// replacement instance gets a fresh worker on the new client.
+	ic.stopEventWorker(instance)
+
+	device := database.Container.NewDevice()
+	client := whatsmeow.NewClient(device, waLog.Stdout("Client/"+instance.ID[:8], config.Get().LogLevel, true))
+	client.AutomaticMessageRerequestFromPhone = config.Get().RerequestFromPhone
+
+	// The Client pointer is read lock-free all over the controllers, so
+	// it is never reassigned in place: a replacement instance is built
+	// around the new client and swapped into the map atomically. Requests
+	// already holding the old instance keep its disconnected client; new
+	// lookups get the replacement.
+	instance.stateMu.Lock()
+	replacement := &Instance{
+		ID:                instance.ID,
+		Name:              instance.Name,
+		Metadata:          instance.Metadata,
+		Client:            client,
+		Status:            models.InstanceStatusCreated,
+		presenceKeepalive: instance.presenceKeepalive,
+		autoRead:          instance.autoRead,
+		autoDownloadMedia: instance.autoDownloadMedia,
+		osName:            instance.osName,
+		platform:          instance.platform,
+	}
+	instance.stateMu.Unlock()
+	ic.setupEventHandlers(replacement)
+	ic.addInstance(replacement)
+
+	database.DB.Model(&models.Instance{}).Where("id = ?", instance.ID).Updates(map[string]interface{}{
+		"status":     models.InstanceStatusCreated,
+		"phone":      "",
+		"user_jid":   "",
+		"device_jid": "",
+	})
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{
+		"instance_id": instance.ID,
+		"status":      models.InstanceStatusCreated,
+		"qr_endpoint": "/instances/" + instance.ID + "/qr",
+	}})
+}
+
+// GetSyncStatus reports whether the initial app-state sync has completed.
+func (ic *InstanceController) GetSyncStatus(c *gin.Context) {
+	instance, ok := ic.getInstance(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "instance not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{
+		"connected": instance.Client.IsConnected(),
+		"synced":    instance.Client.IsLoggedIn(),
+	}})
+}
+
+// appStateCollections lists the app-state collections WhatsApp syncs
+// after pairing, in the order the server sends them.
+var appStateCollections = []appstate.WAPatchName{
+	appstate.WAPatchCriticalBlock,
+	appstate.WAPatchCriticalUnblockLow,
+	appstate.WAPatchRegularLow,
+	appstate.WAPatchRegularHigh,
+	appstate.WAPatchRegular,
+}
+
+// GetSyncProgress reports the initial sync as an approximate percentage
+// plus per-collection state, so dashboards can show a progress bar after
+// pairing instead of an opaque wait.
+func (ic *InstanceController) GetSyncProgress(c *gin.Context) {
+	instance, ok := ic.getInstance(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "instance not found"})
+		return
+	}
+	instance.syncMu.Lock()
+	collections := gin.H{}
+	synced := 0
+	for _, name := range appStateCollections {
+		state := "pending"
+		if instance.syncedPatches[string(name)] {
+			state = "synced"
+			synced++
+		}
+		collections[string(name)] = state
+	}
+	offline := gin.H{
+		"pending_items": instance.offlineTotal,
+		"completed":     instance.offlineDone,
+	}
+	instance.syncMu.Unlock()
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{
+		"connected":    instance.Client.IsConnected(),
+		"logged_in":    instance.Client.IsLoggedIn(),
+		"percent":      synced * 100 / len(appStateCollections),
+		"collections":  collections,
+		"offline_sync": offline,
+	}})
+}
+
+// WhatsApp caps push names at 25 characters.
+const maxPushNameLength = 25
+
+// SetPushName changes the display name recipients see for this account.
+func (ic *InstanceController) SetPushName(c *gin.Context) {
+	var req models.SetPushNameRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	name := strings.TrimSpace(req.PushName)
+	if name == "" || utf8.RuneCountInString(name) > maxPushNameLength {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   fmt.Sprintf("push_name must be 1-%d characters", maxPushNameLength),
+		})
+		return
+	}
+	instance, ok := ic.getInstance(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "instance not found"})
+		return
+	}
+	if !instance.Client.IsLoggedIn() {
+		c.JSON(http.StatusConflict, gin.H{"success": false, "error": "instance is not connected"})
+		return
+	}
+	if err := instance.Client.SendAppState(appstate.BuildSettingPushName(name)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	instance.Client.Store.PushName = name
+	_ = instance.Client.Store.Save()
+	database.DB.Model(&models.Instance{}).Where("id = ?", instance.ID).Update("push_name", name)
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{"push_name": name}})
+}
+
+// disappearingTimers maps the accepted API values onto the WhatsApp
+// disappearing-message durations.
+var disappearingTimers = map[string]time.Duration{
+	"off": whatsmeow.DisappearingTimerOff,
+	"24h": whatsmeow.DisappearingTimer24Hours,
+	"7d":  whatsmeow.DisappearingTimer7Days,
+	"90d": whatsmeow.DisappearingTimer90Days,
+}
+
+// SetDisappearingDefault sets the account-wide default disappearing
+// timer that new chats inherit. Existing chats keep whatever per-chat
+// timer they already have; those are changed through the chat, not here.
+func (ic *InstanceController) SetDisappearingDefault(c *gin.Context) {
+	var req struct {
+		Duration string `json:"duration" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	timer, valid := disappearingTimers[req.Duration]
+	if !valid {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "duration must be off, 24h, 7d or 90d"})
+		return
+	}
+	instance, ok := ic.getInstance(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "instance not found"})
+		return
+	}
+	if !instance.Client.IsLoggedIn() {
+		c.JSON(http.StatusConflict, gin.H{"success": false, "error": "instance is not connected"})
+		return
+	}
+	if err := instance.Client.SetDefaultDisappearingTimer(timer); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{
+		"duration": req.Duration,
+		"seconds":  int(timer.Seconds()),
+		"scope":    "new chats only; existing chats keep their per-chat timer",
+	}})
+}
+
+// GetReadReceipts reports the account's read-receipt privacy setting.
+func (ic *InstanceController) GetReadReceipts(c *gin.Context) {
+	instance, ok := ic.getInstance(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "instance not found"})
+		return
+	}
+	if !instance.Client.IsLoggedIn() {
+		c.JSON(http.StatusConflict, gin.H{"success": false, "error": "instance is not connected"})
+		return
+	}
+	settings, err := instance.Client.TryFetchPrivacySettings(false)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	enabled := settings.ReadReceipts != types.PrivacySettingNone
+	ic.persistReadReceipts(instance, enabled)
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{
+		"read_receipts_enabled": enabled,
+	}})
+}
+
+// SetReadReceipts toggles the account's read-receipt privacy setting.
+func (ic *InstanceController) SetReadReceipts(c *gin.Context) {
+	var req struct {
+		Enabled *bool `json:"enabled" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	instance, ok := ic.getInstance(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "instance not found"})
+		return
+	}
+	if !instance.Client.IsLoggedIn() {
+		c.JSON(http.StatusConflict, gin.H{"success": false, "error": "instance is not connected"})
+		return
+	}
+	value := types.PrivacySettingAll
+	if !*req.Enabled {
+		value = types.PrivacySettingNone
+	}
+	if _, err := instance.Client.SetPrivacySetting(types.PrivacySettingTypeReadReceipts, value); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	ic.persistReadReceipts(instance, *req.Enabled)
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{
+		"read_receipts_enabled": *req.Enabled,
+	}})
+}
+
+// persistReadReceipts mirrors the observed read-receipt setting into the
+// instance row.
+func (ic *InstanceController) persistReadReceipts(instance *Instance, enabled bool) {
+	database.DB.Model(&models.Instance{}).
+		Where("id = ?", instance.ID).
+		Update("read_receipts_disabled", !enabled)
+}
+
+// GetMe returns the account identity behind an instance.
+func (ic *InstanceController) GetMe(c *gin.Context) {
+	instance, ok := ic.getInstance(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "instance not found"})
+		return
+	}
+	if instance.Client.Store.ID == nil {
+		c.JSON(http.StatusConflict, gin.H{"success": false, "error": "instance is not paired"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{
+		"jid":       instance.Client.Store.ID.ToNonAD().String(),
+		"phone":     instance.Client.Store.ID.User,
+		"push_name": instance.Client.Store.PushName,
+		"platform":  instance.Client.Store.Platform,
+	}})
+}
+
+// ExportInstance streams every stored record for an instance as one JSON
+// document: the instance row, webhook configuration, call settings and
+// logs, statuses, event logs and all messages. Messages are streamed in
+// batches so large histories never sit in memory at once. The whatsmeow
+// session keys are deliberately not included; they cannot be restored
+// into another store safely and exporting them would leak the session.
+func (ic *InstanceController) ExportInstance(c *gin.Context) {
+	instance, ok := ic.getInstance(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "instance not found"})
+		return
+	}
+
+	c.Header("Content-Type", "application/json")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=instance-%s-export.json", instance.ID))
+	w := c.Writer
+	enc := json.NewEncoder(w)
+
+	writeSection := func(name string, value interface{}) {
+		fmt.Fprintf(w, "%q:", name)
+		_ = enc.Encode(value)
+	}
+
+	var record models.Instance
+	database.DB.Where("id = ?", instance.ID).First(&record)
+	var webhooks []models.Webhook
+	database.DB.Where("instance_id = ?", instance.ID).Find(&webhooks)
+	var filters []models.WebhookFilter
+	if len(webhooks) > 0 {
+		ids := make([]string, 0, len(webhooks))
+		for _, wh := range webhooks {
+			ids = append(ids, wh.ID)
+		}
+		database.DB.Where("webhook_id IN ?", ids).Find(&filters)
+	}
+	var callSettings []models.CallSettings
+	database.DB.Where("instance_id = ?", instance.ID).Find(&callSettings)
+	var callLogs []models.CallLog
+	database.DB.Where("instance_id = ?", instance.ID).Find(&callLogs)
+	var statuses []models.Status
+	database.DB.Where("instance_id = ?", instance.ID).Find(&statuses)
+
+	w.Write([]byte("{"))
+	writeSection("exported_at", time.Now().UTC())
+	w.Write([]byte(","))
+	writeSection("instance", record)
+	w.Write([]byte(","))
+	writeSection("webhooks", webhooks)
+	w.Write([]byte(","))
+	writeSection("webhook_filters", filters)
+	w.Write([]byte(","))
+	writeSection("call_settings", callSettings)
+	w.Write([]byte(","))
+	writeSection("call_logs", callLogs)
+	w.Write([]byte(","))
+	writeSection("statuses", statuses)
+
+	w.Write([]byte(`,"messages":[`))
+	first := true
+	var batch []models.Message
+	database.DB.Where("instance_id = ?", instance.ID).
+		FindInBatches(&batch, 500, func(tx *gorm.DB, _ int) error {
+			for i := range batch {
+				if !first {
+					w.Write([]byte(","))
+				}
+				first = false
+				_ = enc.Encode(batch[i])
+			}
+			w.(http.Flusher).Flush()
+			return nil
+		})
+	w.Write([]byte("]}"))
+}
+
+// GetInstanceStats reports message volume and decryption-failure metrics
+// for an instance. Persistently high failure counts usually mean the
+// session needs to be re-paired.
+func (ic *InstanceController) GetInstanceStats(c *gin.Context) {
+	instance, ok := ic.getInstance(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "instance not found"})
+		return
+	}
+
+	var sent, received, failedTotal, failedDay int64
+	database.DB.Model(&models.Message{}).
+		Where("instance_id = ? AND from_me = ?", instance.ID, true).Count(&sent)
+	database.DB.Model(&models.Message{}).
+		Where("instance_id = ? AND from_me = ?", instance.ID, false).Count(&received)
+	database.DB.Model(&models.EventLog{}).
+		Where("instance_id = ? AND event_type = ?", instance.ID, "message.undecryptable").
+		Count(&failedTotal)
+	database.DB.Model(&models.EventLog{}).
+		Where("instance_id = ? AND event_type = ? AND created_at >= ?",
+			instance.ID, "message.undecryptable", time.Now().Add(-24*time.Hour)).
+		Count(&failedDay)
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{
+		"instance_id":       instance.ID,
+		"messages_sent":     sent,
+		"messages_received": received,
+		"event_buffer": gin.H{
+			"depth":    len(instance.events),
+			"capacity": cap(instance.events),
+			"dropped":  instance.droppedEvents.Load(),
+			"policy":   config.Get().EventOverflowPolicy,
+		},
+		"decryption_failures": gin.H{
+			"total":          failedTotal,
+			"last_24h":       failedDay,
+			"auto_rerequest": config.Get().RerequestFromPhone,
+		},
+	}})
+}
+
+// GetDeviceDebugInfo returns the non-secret parts of the whatsmeow device
+// store for debugging pairing issues. Key material is reported as presence
+// only; the keys themselves never leave the store.
+func (ic *InstanceController) GetDeviceDebugInfo(c *gin.Context) {
+	instance, ok := ic.getInstance(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "instance not found"})
+		return
+	}
+	store := instance.Client.Store
+
+	data := gin.H{
+		"instance_id":         instance.ID,
+		"initialized":         store.Initialized,
+		"paired":              store.ID != nil,
+		"registration_id_set": store.RegistrationID != 0,
+		"push_name":           store.PushName,
+		"platform":            store.Platform,
+		"business_name":       store.BusinessName,
+		"account_signed":      store.Account != nil,
+		"keys": gin.H{
+			"noise_key":      store.NoiseKey != nil,
+			"identity_key":   store.IdentityKey != nil,
+			"signed_pre_key": store.SignedPreKey != nil,
+			"adv_secret_key": len(store.AdvSecretKey) > 0,
+		},
+	}
+	if store.ID != nil {
+		data["jid"] = store.ID.String()
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": data})
+}
+
+// storeInviteReset records an invite-link rotation for later auditing.
+func (ic *InstanceController) storeInviteReset(instance *Instance, e *events.GroupInfo) {
+	reset := models.GroupInviteReset{
+		InstanceID: instance.ID,
+		GroupJID:   e.JID.String(),
+		NewLink:    *e.NewInviteLink,
+		Timestamp:  e.Timestamp,
+	}
+	if e.Sender != nil {
+		reset.ResetBy = e.Sender.ToNonAD().String()
+	}
+	database.DB.Create(&reset)
+}
+
+// persistStatus mirrors the runtime status into the instances table.
+func (ic *InstanceController) persistStatus(instance *Instance) {
+	instance.stateMu.Lock()
+	status := instance.Status
+	instance.stateMu.Unlock()
+	updates := map[string]interface{}{"status": status}
+	if instance.Client.Store.ID != nil {
+		updates["user_jid"] = instance.Client.Store.ID.ToNonAD().String()
+		updates["device_jid"] = instance.Client.Store.ID.String()
+		updates["phone"] = instance.Client.Store.ID.User
+	}
+	database.DB.Model(&models.Instance{}).Where("id = ?", instance.ID).Updates(updates)
+}
+
+// setupEventHandlers routes whatsmeow events through a bounded buffer to
+// the internal handler that maintains state and storage and the webhook
+// service handler that fans events out to configured webhooks. The
+// whatsmeow callback only enqueues, so slow handlers never back up the
+// client's event loop.
+func (ic *InstanceController) setupEventHandlers(instance *Instance) {
+	events := make(chan interface{}, config.Get().EventBufferSize)
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	instance.events = events
+	instance.eventsDone = done
+	instance.eventsStopped = stopped
+	webhookHandler := ic.webhookService.EventHandler(instance.ID)
+	go func() {
+		defer close(stopped)
+		for {
+			select {
+			case <-done:
+				return
+			case evt := <-events:
+				countEvent(instance, evt)
+				ic.handleEvent(instance, evt)
+				webhookHandler(evt)
+			}
+		}
+	}()
+	// The handler closes over this worker's own channels rather than
+	// reading them off the instance: after a session reset the old
+	// client's handler may still fire, and it must hit its stopped
+	// worker, not the replacement's channel.
+	instance.eventHandlerID = instance.Client.AddEventHandler(func(evt interface{}) {
+		ic.enqueueEvent(instance, events, done, evt)
+	})
+}
+
+// stopEventWorker detaches the instance's whatsmeow handler and signals
+// the event worker to exit. The handler is removed first so nothing new
+// is produced; events already in flight are then discarded by
+// enqueueEvent instead of being sent on a closed channel — whatsmeow
+// keeps dispatching to handlers from background goroutines after
+// Disconnect returns.
+func (ic *InstanceController) stopEventWorker(instance *Instance) {
+	instance.Client.RemoveEventHandler(instance.eventHandlerID)
+	close(instance.eventsDone)
+	// Wait for the worker to finish the event it is on, so none of its
+	// state writes trail the shutdown.
+	<-instance.eventsStopped
+}
+
+// enqueueEvent hands an event to the instance worker. When the buffer is
+// full the configured overflow policy decides between dropping the event
+// and blocking until there is room. Events arriving after the worker was
+// stopped are discarded.
+func (ic *InstanceController) enqueueEvent(instance *Instance, events chan<- interface{}, done <-chan struct{}, evt interface{}) {
+	select {
+	case <-done:
+		return
+	default:
+	}
+	if config.Get().EventOverflowPolicy == "block" {
+		select {
+		case events <- evt:
+		case <-done:
+		}
+		return
+	}
+	select {
+	case events <- evt:
+	default:
+		instance.droppedEvents.Add(1)
+		log.Printf("instance %s: event buffer full, dropping %T", instance.ID, evt)
+	}
+}
+
+// handleEvent maintains instance status and persists messages, receipts,
+// statuses and calls.
+func (ic *InstanceController) handleEvent(instance *Instance, evt interface{}) {
+	switch e := evt.(type) {
+	case *events.Connected:
+		instance.stateMu.Lock()
+		instance.Status = models.InstanceStatusConnected
+		instance.ConnectedAt = time.Now()
+		instance.QRCode = ""
+		keepalive := instance.presenceKeepalive
+		instance.stateMu.Unlock()
+		ic.deviceUsageMu.Lock()
+		delete(ic.deviceUsage, instance.ID)
+		ic.deviceUsageMu.Unlock()
+		instance.eventCountsMu.Lock()
+		instance.eventCounts = make(map[string]uint64)
+		instance.eventCountsMu.Unlock()
+		ic.persistStatus(instance)
+		if keepalive {
+			ic.startKeepalive(instance)
+		}
+	case *events.Disconnected:
+		instance.stateMu.Lock()
+		instance.Status = models.InstanceStatusDisconnected
+		instance.stateMu.Unlock()
+		ic.persistStatus(instance)
+		ic.stopKeepalive(instance)
+	case *events.LoggedOut:
+		instance.stateMu.Lock()
+		instance.Status = models.InstanceStatusDisconnected
+		instance.stateMu.Unlock()
+		instance.syncMu.Lock()
+		instance.syncedPatches = nil
+		instance.offlineTotal = 0
+		instance.offlineDone = false
+		instance.syncMu.Unlock()
+		ic.persistStatus(instance)
+		ic.stopKeepalive(instance)
+	case *events.PairSuccess:
+		ic.checkDuplicatePairing(instance, e.ID)
+		ic.persistStatus(instance)
+	case *events.AppStateSyncComplete:
+		instance.syncMu.Lock()
+		if instance.syncedPatches == nil {
+			instance.syncedPatches = make(map[string]bool)
+		}
+		instance.syncedPatches[string(e.Name)] = true
+		instance.syncMu.Unlock()
+	case *events.OfflineSyncPreview:
+		instance.syncMu.Lock()
+		instance.offlineTotal = e.Total
+		instance.offlineDone = false
+		instance.syncMu.Unlock()
+	case *events.OfflineSyncCompleted:
+		instance.syncMu.Lock()
+		instance.offlineDone = true
+		instance.syncMu.Unlock()
+	case *events.Message:
+		ic.storeMessage(instance, e)
+		ic.storePollVote(instance, e)
+		ic.autoMarkRead(instance, e)
+		ic.autoDownloadIncomingMedia(instance, e)
+	case *events.Receipt:
+		ic.storeReceipt(instance, e)
+	case *events.CallOffer:
+		if ic.callController != nil {
+			ic.callController.handleIncomingCall(instance, e)
+		}
+	case *events.GroupInfo:
+		if e.NewInviteLink != nil {
+			ic.storeInviteReset(instance, e)
+		}
+	case *events.Star:
+		database.DB.Model(&models.Message{}).
+			Where("instance_id = ? AND chat_jid = ? AND message_id = ?",
+				instance.ID, e.ChatJID.String(), e.MessageID).
+			Update("starred", e.Action.GetStarred())
+	case *events.LabelEdit:
+		ic.storeLabelEdit(instance, e)
+	case *events.LabelAssociationChat:
+		ic.storeChatLabel(instance, e)
+	case *events.StreamError:
+		ic.recordSessionError(instance, "stream error: "+e.Code)
+		ic.setStatus(instance, models.InstanceStatusError)
+		ic.persistStatus(instance)
+	case *events.ClientOutdated:
+		ic.recordSessionError(instance, "client outdated: WhatsApp rejected the connection, the client library needs updating")
+		ic.setStatus(instance, models.InstanceStatusError)
+		ic.persistStatus(instance)
+	case *events.TemporaryBan:
+		ic.recordSessionError(instance, e.String())
+		ic.setStatus(instance, models.InstanceStatusBanned)
+		ic.persistStatus(instance)
+	case *events.ConnectFailure:
+		ic.recordSessionError(instance, "connect failure "+e.Reason.NumberString()+": "+e.Reason.String())
+		if e.Reason == events.ConnectFailureTempBanned {
+			ic.setStatus(instance, models.InstanceStatusBanned)
+		} else {
+			ic.setStatus(instance, models.InstanceStatusError)
+		}
+		ic.persistStatus(instance)
+	}
+}
+
+// setStatus updates the runtime status under the instance state lock.
+func (ic *InstanceController) setStatus(instance *Instance, status string) {
+	instance.stateMu.Lock()
+	instance.Status = status
+	instance.stateMu.Unlock()
+}
+
+// eventKind buckets a whatsmeow event into the coarse categories used by
+// the event counters.
+func eventKind(evt interface{}) string {
+	switch evt.(type) {
+	case *events.Message:
+		return "messages"
+	case *events.Receipt:
+		return "receipts"
+	case *events.Presence, *events.ChatPresence:
+		return "presence"
+	case *events.CallOffer, *events.CallOfferNotice, *events.CallAccept, *events.CallTerminate:
+		return "calls"
+	case *events.GroupInfo, *events.JoinedGroup:
+		return "group_updates"
+	case *events.Connected, *events.Disconnected, *events.LoggedOut, *events.StreamError, *events.ConnectFailure, *events.TemporaryBan, *events.ClientOutdated:
+		return "connection"
+	default:
+		return "other"
+	}
+}
+
+// countEvent bumps the per-instance counter for the event's kind.
+func countEvent(instance *Instance, evt interface{}) {
+	kind := eventKind(evt)
+	instance.eventCountsMu.Lock()
+	if instance.eventCounts == nil {
+		instance.eventCounts = make(map[string]uint64)
+	}
+	instance.eventCounts[kind]++
+	instance.eventCountsMu.Unlock()
+}
+
+// GetEventCounts returns how many events of each kind the instance has
+// seen since it last connected.
+func (ic *InstanceController) GetEventCounts(c *gin.Context) {
+	instance, ok := ic.getInstance(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "instance not found"})
+		return
+	}
+	counts := make(map[string]uint64)
+	instance.eventCountsMu.Lock()
+	for kind, n := range instance.eventCounts {
+		counts[kind] = n
+	}
+	instance.eventCountsMu.Unlock()
+	data := gin.H{"counts": counts}
+	instance.stateMu.Lock()
+	if !instance.ConnectedAt.IsZero() {
+		data["since"] = instance.ConnectedAt
+	}
+	instance.stateMu.Unlock()
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": data})
+}
+
+// exportEventsBatchSize bounds how many event rows are loaded per query
+// while streaming an NDJSON export, so the window size never dictates
+// memory use.
+const exportEventsBatchSize = 500
+
+// ExportEventsNDJSON streams the stored events of an instance within a
+// time window as newline-delimited JSON, one event per line, for bulk
+// ingestion into data warehouses. Unlike the webhook replay this is a
+// read-only historical pull; nothing is re-delivered.
+func (ic *InstanceController) ExportEventsNDJSON(c *gin.Context) {
+	instance, ok := ic.getInstance(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "instance not found"})
+		return
+	}
+	from, err := time.Parse(time.RFC3339, c.Query("from"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "from is required (RFC3339)"})
+		return
+	}
+	to := time.Now()
+	if raw := c.Query("to"); raw != "" {
+		to, err = time.Parse(time.RFC3339, raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "to must be RFC3339"})
+			return
+		}
+	}
+	if !to.After(from) {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "to must be after from"})
+		return
+	}
+
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=instance-%s-events.ndjson", instance.ID))
+	w := c.Writer
+	enc := json.NewEncoder(w)
+	var lastID uint
+	for {
+		var batch []models.EventLog
+		if err := database.DB.
+			Where("instance_id = ? AND created_at >= ? AND created_at <= ? AND id > ?", instance.ID, from, to, lastID).
+			Order("id ASC").
+			Limit(exportEventsBatchSize).
+			Find(&batch).Error; err != nil {
+			// Headers are already out; stop the stream instead of writing
+			// a JSON error into the NDJSON body.
+			return
+		}
+		if len(batch) == 0 {
+			return
+		}
+		for _, entry := range batch {
+			line := map[string]interface{}{
+				"id":          entry.ID,
+				"event_type":  entry.EventType,
+				"recorded_at": entry.CreatedAt,
+				"payload":     json.RawMessage(entry.Payload),
+			}
+			if !json.Valid([]byte(entry.Payload)) {
+				line["payload"] = entry.Payload
+			}
+			_ = enc.Encode(line)
+		}
+		lastID = batch[len(batch)-1].ID
+		w.Flush()
+	}
+}
+
+// GetInstancesConsistency compares the in-memory instances map against
+// the instances table and reports drift in both directions, for use after
+// crashes or manual DB edits.
+func (ic *InstanceController) GetInstancesConsistency(c *gin.Context) {
+	memoryOnly, dbOnly, consistent, err := ic.instancesDrift()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{
+		"consistent":       len(memoryOnly) == 0 && len(dbOnly) == 0,
+		"consistent_count": consistent,
+		"memory_only":      memoryOnly,
+		"db_only":          dbOnly,
+	}})
+}
+
+// RepairInstancesConsistency reconciles drift between the instances map
+// and the instances table: in-memory instances missing a row get one
+// recreated, and rows without a live client are marked disconnected.
+func (ic *InstanceController) RepairInstancesConsistency(c *gin.Context) {
+	memoryOnly, dbOnly, _, err := ic.instancesDrift()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	recreated := make([]string, 0, len(memoryOnly))
+	for _, id := range memoryOnly {
+		instance, ok := ic.getInstance(id)
+		if !ok {
+			continue
+		}
+		instance.stateMu.Lock()
+		row := models.Instance{
+			ID:     instance.ID,
+			Name:   instance.Name,
+			Status: instance.Status,
+		}
+		instance.stateMu.Unlock()
+		if err := database.DB.Create(&row).Error; err != nil {
+			continue
+		}
+		recreated = append(recreated, id)
+	}
+	marked := make([]string, 0, len(dbOnly))
+	for _, id := range dbOnly {
+		if err := database.DB.Model(&models.Instance{}).
+			Where("id = ? AND status = ?", id, models.InstanceStatusConnected).
+			Update("status", models.InstanceStatusDisconnected).Error; err != nil {
+			continue
+		}
+		marked = append(marked, id)
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{
+		"recreated_rows":      recreated,
+		"marked_disconnected": marked,
+	}})
+}
+
+// instancesDrift diffs the instances map against the instances table,
+// returning the IDs present only in memory, only in the DB, and the count
+// present in both.
+func (ic *InstanceController) instancesDrift() (memoryOnly, dbOnly []string, consistent int, err error) {
+	var rows []models.Instance
+	if err = database.DB.Find(&rows).Error; err != nil {
+		return nil, nil, 0, err
+	}
+	inDB := make(map[string]bool, len(rows))
+	for _, row := range rows {
+		inDB[row.ID] = true
+	}
+	instances := ic.snapshotInstances()
+	memoryOnly = make([]string, 0)
+	dbOnly = make([]string, 0)
+	for id := range instances {
+		if inDB[id] {
+			consistent++
+		} else {
+			memoryOnly = append(memoryOnly, id)
+		}
+	}
+	for _, row := range rows {
+		if _, ok := instances[row.ID]; !ok {
+			dbOnly = append(dbOnly, row.ID)
+		}
+	}
+	sort.Strings(memoryOnly)
+	sort.Strings(dbOnly)
+	return memoryOnly, dbOnly, consistent, nil
+}
+
+// maxLinkedDevices is WhatsApp's cap on companion (linked) devices per
+// account, alongside the primary phone.
+const maxLinkedDevices = 4
+
+// deviceUsageTTL is how long a fetched linked-device count is served from
+// cache. whatsmeow emits no dedicated device-list event, so the cache is
+// kept short and additionally dropped on reconnect, when the device list
+// is re-synced anyway.
+const deviceUsageTTL = time.Minute
+
+// GetDeviceLimit reports the account's linked-device limit and current
+// usage so operators can tell whether another device can still be paired.
+func (ic *InstanceController) GetDeviceLimit(c *gin.Context) {
+	instance, ok := ic.getInstance(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "instance not found"})
+		return
+	}
+	if !instance.Client.IsLoggedIn() || instance.Client.Store.ID == nil {
+		c.JSON(http.StatusConflict, gin.H{"success": false, "error": "instance is not logged in; the device list is only available for a paired account"})
+		return
+	}
+
+	ic.deviceUsageMu.Lock()
+	usage, cached := ic.deviceUsage[instance.ID]
+	ic.deviceUsageMu.Unlock()
+	if !cached || time.Since(usage.fetchedAt) > deviceUsageTTL {
+		devices, err := instance.Client.GetUserDevices([]types.JID{instance.Client.Store.ID.ToNonAD()})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+			return
+		}
+		usage = deviceUsage{total: len(devices), fetchedAt: time.Now()}
+		for _, device := range devices {
+			// Device 0 is the primary phone; everything else counts
+			// against the linked-device limit.
+			if device.Device != 0 {
+				usage.linked++
+			}
+		}
+		ic.deviceUsageMu.Lock()
+		ic.deviceUsage[instance.ID] = usage
+		ic.deviceUsageMu.Unlock()
+		cached = false
+	}
+
+	remaining := maxLinkedDevices - usage.linked
+	if remaining < 0 {
+		remaining = 0
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{
+		"max_linked_devices": maxLinkedDevices,
+		"linked_devices":     usage.linked,
+		"total_devices":      usage.total,
+		"remaining":          remaining,
+		"cached":             cached,
+		"fetched_at":         usage.fetchedAt,
+	}})
+}
+
+// GetInstanceDuplicates lists groups of instances bound to the same
+// logged-in account, merging live client state with the persisted JIDs of
+// instances that are not currently connected.
+func (ic *InstanceController) GetInstanceDuplicates(c *gin.Context) {
+	groups, err := ic.duplicateInstanceGroups()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	out := make([]gin.H, 0, len(groups))
+	jids := make([]string, 0, len(groups))
+	for jid := range groups {
+		jids = append(jids, jid)
+	}
+	sort.Strings(jids)
+	for _, jid := range jids {
+		out = append(out, gin.H{"user_jid": jid, "instances": groups[jid]})
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{
+		"duplicate_groups": out,
+		"count":            len(out),
+	}})
+}
+
+// duplicateInstanceGroups groups instances by logged-in user JID and keeps
+// only the JIDs claimed by more than one instance. The live client store
+// wins over the persisted row for instances that are in memory.
+func (ic *InstanceController) duplicateInstanceGroups() (map[string][]gin.H, error) {
+	var rows []models.Instance
+	if err := database.DB.Where("user_jid != ?", "").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	jidByID := make(map[string]string, len(rows))
+	nameByID := make(map[string]string, len(rows))
+	statusByID := make(map[string]string, len(rows))
+	for _, row := range rows {
+		jidByID[row.ID] = row.UserJID
+		nameByID[row.ID] = row.Name
+		statusByID[row.ID] = row.Status
+	}
+	live := ic.snapshotInstances()
+	for id, instance := range live {
+		if instance.Client.Store.ID != nil {
+			jidByID[id] = instance.Client.Store.ID.ToNonAD().String()
+			instance.stateMu.Lock()
+			nameByID[id] = instance.Name
+			statusByID[id] = instance.Status
+			instance.stateMu.Unlock()
+		}
+	}
+	byJID := make(map[string][]gin.H)
+	ids := make([]string, 0, len(jidByID))
+	for id := range jidByID {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		jid := jidByID[id]
+		_, isLive := live[id]
+		byJID[jid] = append(byJID[jid], gin.H{
+			"id":     id,
+			"name":   nameByID[id],
+			"status": statusByID[id],
+			"live":   isLive,
+		})
+	}
+	for jid, group := range byJID {
+		if len(group) < 2 {
+			delete(byJID, jid)
+		}
+	}
+	return byJID, nil
+}
+
+// checkDuplicatePairing warns loudly when a pairing binds a phone number
+// that another instance already holds. The pairing itself cannot be
+// blocked — WhatsApp has already accepted it — so the duplicate is
+// surfaced through the log, the instance's last error and a webhook
+// event instead of being discovered later as silent session conflicts.
+func (ic *InstanceController) checkDuplicatePairing(instance *Instance, paired types.JID) {
+	jid := paired.ToNonAD().String()
+	duplicates := make([]string, 0)
+	live := ic.snapshotInstances()
+	for id, other := range live {
+		if id == instance.ID || other.Client.Store.ID == nil {
+			continue
+		}
+		if other.Client.Store.ID.ToNonAD().String() == jid {
+			duplicates = append(duplicates, id)
+		}
+	}
+	var rows []models.Instance
+	database.DB.Where("user_jid = ? AND id != ?", jid, instance.ID).Find(&rows)
+	for _, row := range rows {
+		if _, isLive := live[row.ID]; !isLive {
+			duplicates = append(duplicates, row.ID)
+		}
+	}
+	if len(duplicates) == 0 {
+		return
+	}
+	sort.Strings(duplicates)
+	log.Printf("instance %s: paired %s which is already bound to instance(s) %s", instance.ID, jid, strings.Join(duplicates, ", "))
+	ic.recordSessionError(instance, "paired a number already bound to instance(s) "+strings.Join(duplicates, ", "))
+	ic.webhookService.Dispatch(instance.ID, "instance.duplicate", map[string]interface{}{
+		"instance_id":            instance.ID,
+		"user_jid":               jid,
+		"duplicate_instance_ids": duplicates,
+	})
+}
+
+// autoMarkRead sends the read receipt for an incoming message when the
+// instance's auto_read setting is on. With read receipts disabled in the
+// account's privacy settings the receipt is sent as read-self, matching
+// what the phone would do.
+func (ic *InstanceController) autoMarkRead(instance *Instance, e *events.Message) {
+	instance.stateMu.Lock()
+	autoRead := instance.autoRead
+	instance.stateMu.Unlock()
+	if !autoRead || e.Info.IsFromMe {
+		return
+	}
+	receiptType := types.ReceiptTypeRead
+	var row models.Instance
+	if err := database.DB.Select("read_receipts_disabled").First(&row, "id = ?", instance.ID).Error; err == nil && row.ReadReceiptsDisabled {
+		receiptType = types.ReceiptTypeReadSelf
+	}
+	if err := instance.Client.MarkRead([]types.MessageID{e.Info.ID}, time.Now(), e.Info.Chat, e.Info.Sender, receiptType); err != nil {
+		log.Printf("instance %s: auto-read for message %s: %v", instance.ID, e.Info.ID, err)
+	}
+}
+
+// autoDownloadIncomingMedia fetches and stores a message's media when the
+// instance's auto_download_media setting is on. Files larger than the
+// configured cap are skipped. The stored path is handed to the webhook
+// service so the message.received payload carries it.
+func (ic *InstanceController) autoDownloadIncomingMedia(instance *Instance, e *events.Message) {
+	instance.stateMu.Lock()
+	autoDownload := instance.autoDownloadMedia
+	instance.stateMu.Unlock()
+	if !autoDownload {
+		return
+	}
+	media := utils.MediaInfo(e.Message)
+	if media == nil {
+		return
+	}
+	cfg := config.Get()
+	if cfg.AutoDownloadMaxBytes > 0 && media.FileLength > uint64(cfg.AutoDownloadMaxBytes) {
+		log.Printf("instance %s: auto-download skipping message %s: %d bytes exceeds cap", instance.ID, e.Info.ID, media.FileLength)
+		return
+	}
+	data, err := instance.Client.DownloadAny(e.Message)
+	if err != nil {
+		log.Printf("instance %s: auto-download for message %s: %v", instance.ID, e.Info.ID, err)
+		return
+	}
+	dir := filepath.Join(cfg.MediaDir, instance.ID)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		log.Printf("instance %s: auto-download for message %s: %v", instance.ID, e.Info.ID, err)
+		return
+	}
+	path := filepath.Join(dir, e.Info.ID+extensionForMimetype(media.Mimetype))
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		log.Printf("instance %s: auto-download for message %s: %v", instance.ID, e.Info.ID, err)
+		return
+	}
+	ic.webhookService.RecordStoredMedia(instance.ID, e.Info.ID, path)
+}
+
+// platformTypes maps the accepted platform names to whatsmeow's
+// DeviceProps enum. Only browser/desktop identities are allowed; mobile
+// platform types confuse the companion registration.
+var platformTypes = map[string]waCompanionReg.DeviceProps_PlatformType{
+	"chrome":  waCompanionReg.DeviceProps_CHROME,
+	"firefox": waCompanionReg.DeviceProps_FIREFOX,
+	"safari":  waCompanionReg.DeviceProps_SAFARI,
+	"edge":    waCompanionReg.DeviceProps_EDGE,
+	"opera":   waCompanionReg.DeviceProps_OPERA,
+	"desktop": waCompanionReg.DeviceProps_DESKTOP,
+}
+
+// platformNames lists the accepted platform names, sorted for stable
+// error messages.
+func platformNames() []string {
+	names := make([]string, 0, len(platformTypes))
+	for name := range platformTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// fingerprintMu serializes connects around the mutation of whatsmeow's
+// process-global DeviceProps, which the library reads while building the
+// registration payload. defaultDeviceOs and defaultDevicePlatformType
+// preserve the library's own values so instances without a custom
+// fingerprint pair with the defaults instead of whatever the previous
+// connect applied.
+var (
+	fingerprintMu             sync.Mutex
+	defaultDeviceOs           = store.DeviceProps.Os
+	defaultDevicePlatformType = store.DeviceProps.PlatformType
+)
+
+// connectWithFingerprint applies the instance's fingerprint to
+// DeviceProps and connects under the fingerprint lock, so concurrent
+// connects — one goroutine per instance on restart — cannot interleave
+// the global write with another instance's registration. The fingerprint
+// only matters to WhatsApp at pairing time.
+func (ic *InstanceController) connectWithFingerprint(instance *Instance) error {
+	fingerprintMu.Lock()
+	defer fingerprintMu.Unlock()
+	store.DeviceProps.Os = defaultDeviceOs
+	store.DeviceProps.PlatformType = defaultDevicePlatformType
+	if instance.osName != "" {
+		store.DeviceProps.Os = proto.String(instance.osName)
+	}
+	if platformType, ok := platformTypes[instance.platform]; ok {
+		store.DeviceProps.PlatformType = platformType.Enum()
+	}
+	return instance.Client.Connect()
+}
+
+// extensionForMimetype maps the common WhatsApp media mimetypes to a file
+// extension; unknown types get ".bin".
+func extensionForMimetype(mimetype string) string {
+	if i := strings.Index(mimetype, ";"); i >= 0 {
+		mimetype = mimetype[:i]
+	}
+	switch mimetype {
+	case "image/jpeg":
+		return ".jpg"
+	case "image/png":
+		return ".png"
+	case "image/webp":
+		return ".webp"
+	case "video/mp4":
+		return ".mp4"
+	case "audio/ogg":
+		return ".ogg"
+	case "audio/mpeg":
+		return ".mp3"
+	case "application/pdf":
+		return ".pdf"
+	default:
+		return ".bin"
+	}
+}
+
+// recordSessionError stores the latest session-level error in memory and
+// on the instance row; it is kept (with its timestamp) after recovery so
+// operators can still see what happened.
+func (ic *InstanceController) recordSessionError(instance *Instance, message string) {
+	now := time.Now()
+	instance.stateMu.Lock()
+	instance.lastError = message
+	instance.lastErrorAt = now
+	instance.stateMu.Unlock()
+	database.DB.Model(&models.Instance{}).Where("id = ?", instance.ID).Updates(map[string]interface{}{
+		"last_error":    message,
+		"last_error_at": now,
+	})
+}
+
+// storeLabelEdit mirrors a label edit from app state into the labels table.
+func (ic *InstanceController) storeLabelEdit(instance *Instance, e *events.LabelEdit) {
+	var label models.Label
+	err := database.DB.
+		Where("instance_id = ? AND label_id = ?", instance.ID, e.LabelID).
+		First(&label).Error
+	if err != nil {
+		database.DB.Create(&models.Label{
+			InstanceID: instance.ID,
+			LabelID:    e.LabelID,
+			Name:       e.Action.GetName(),
+			Color:      e.Action.GetColor(),
+			Deleted:    e.Action.GetDeleted(),
+		})
+		return
+	}
+	database.DB.Model(&label).Updates(map[string]interface{}{
+		"name":    e.Action.GetName(),
+		"color":   e.Action.GetColor(),
+		"deleted": e.Action.GetDeleted(),
+	})
+}
+
+// storeChatLabel mirrors a chat (un)labeling from app state into the
+// chat_labels table.
+func (ic *InstanceController) storeChatLabel(instance *Instance, e *events.LabelAssociationChat) {
+	chat := e.JID.String()
+	if !e.Action.GetLabeled() {
+		database.DB.
+			Where("instance_id = ? AND chat_jid = ? AND label_id = ?", instance.ID, chat, e.LabelID).
+			Delete(&models.ChatLabel{})
+		return
+	}
+	var existing models.ChatLabel
+	err := database.DB.
+		Where("instance_id = ? AND chat_jid = ? AND label_id = ?", instance.ID, chat, e.LabelID).
+		First(&existing).Error
+	if err != nil {
+		database.DB.Create(&models.ChatLabel{
+			InstanceID: instance.ID,
+			ChatJID:    chat,
+			LabelID:    e.LabelID,
+		})
+	}
+}
+
+// storePollVote decrypts an incoming poll update for a poll sent through
+// the API and upserts the voter's latest selection. Votes on polls this
+// API did not create are skipped: without the stored options their hashes
+// cannot be resolved back to names.
+func (ic *InstanceController) storePollVote(instance *Instance, e *events.Message) {
+	pollUpdate := e.Message.GetPollUpdateMessage()
+	if pollUpdate == nil {
+		return
+	}
+	pollID := pollUpdate.GetPollCreationMessageKey().GetID()
+	var poll models.Poll
+	if err := database.DB.Where("instance_id = ? AND message_id = ?", instance.ID, pollID).First(&poll).Error; err != nil {
+		return
+	}
+	vote, err := instance.Client.DecryptPollVote(e)
+	if err != nil {
+		log.Printf("instance %s: decrypting poll vote for %s: %v", instance.ID, pollID, err)
+		return
+	}
+	var options []string
+	_ = json.Unmarshal([]byte(poll.Options), &options)
+	byHash := make(map[string]string, len(options))
+	for i, hash := range whatsmeow.HashPollOptions(options) {
+		byHash[string(hash)] = options[i]
+	}
+	selected := make([]string, 0, len(vote.GetSelectedOptions()))
+	for _, hash := range vote.GetSelectedOptions() {
+		if name, ok := byHash[string(hash)]; ok {
+			selected = append(selected, name)
+		}
+	}
+	encoded, _ := json.Marshal(selected)
+	ic.webhookService.RecordPollVote(instance.ID, e.Info.ID, selected)
+	voter := e.Info.Sender.ToNonAD().String()
+	var existing models.PollVote
+	err = database.DB.
+		Where("instance_id = ? AND poll_message_id = ? AND voter_jid = ?", instance.ID, pollID, voter).
+		First(&existing).Error
+	if err != nil {
+		database.DB.Create(&models.PollVote{
+			InstanceID:      instance.ID,
+			PollMessageID:   pollID,
+			VoterJID:        voter,
+			SelectedOptions: string(encoded),
+		})
+		return
+	}
+	database.DB.Model(&existing).Update("selected_options", string(encoded))
+}
+
+// storeMessage persists an incoming message, or a contact status when the
+// chat is the status broadcast.
+func (ic *InstanceController) storeMessage(instance *Instance, e *events.Message) {
+	msgType := utils.MessageType(e.Message)
+	content := utils.MessageContent(e.Message)
+	media := utils.MediaInfo(e.Message)
+
+	if e.Info.Chat.String() == "status@broadcast" {
+		status := models.Status{
+			InstanceID: instance.ID,
+			StatusID:   e.Info.ID,
+			AuthorJID:  e.Info.Sender.ToNonAD().String(),
+			Type:       msgType,
+			Content:    content,
+			Timestamp:  e.Info.Timestamp,
+			ExpiresAt:  e.Info.Timestamp.Add(24 * time.Hour),
+		}
+		if media != nil {
+			status.MediaURL = media.URL
+			status.DirectPath = media.DirectPath
+			status.MediaKey = media.MediaKey
+			status.FileEncSHA256 = media.FileEncSHA256
+			status.FileSHA256 = media.FileSHA256
+			status.FileLength = media.FileLength
+			status.Mimetype = media.Mimetype
+		}
+		database.DB.Create(&status)
+		return
+	}
+
+	msg := models.Message{
+		InstanceID: instance.ID,
+		MessageID:  e.Info.ID,
+		ChatJID:    e.Info.Chat.String(),
+		SenderJID:  e.Info.Sender.ToNonAD().String(),
+		FromMe:     e.Info.IsFromMe,
+		Type:       msgType,
+		Content:    content,
+		Status:     models.MessageStatusReceived,
+		Timestamp:  e.Info.Timestamp,
+	}
+	if e.Info.IsFromMe {
+		msg.Status = models.MessageStatusSent
+	}
+	if media != nil {
+		msg.MediaURL = media.URL
+		msg.DirectPath = media.DirectPath
+		msg.MediaKey = media.MediaKey
+		msg.FileEncSHA256 = media.FileEncSHA256
+		msg.FileSHA256 = media.FileSHA256
+		msg.FileLength = media.FileLength
+		msg.Mimetype = media.Mimetype
+	}
+	database.DB.Create(&msg)
+}
+
+// storeReceipt persists delivery/read receipts and bumps the status of the
+// affected stored messages.
+func (ic *InstanceController) storeReceipt(instance *Instance, e *events.Receipt) {
+	receiptType := "delivered"
+	newStatus := models.MessageStatusDelivered
+	switch e.Type {
+	case "read", "read-self":
+		receiptType = "read"
+		newStatus = models.MessageStatusRead
+	case "played":
+		receiptType = "played"
+		newStatus = models.MessageStatusRead
+	}
+	for _, id := range e.MessageIDs {
+		database.DB.Create(&models.MessageReceipt{
+			InstanceID: instance.ID,
+			MessageID:  id,
+			ChatJID:    e.Chat.String(),
+			SenderJID:  e.Sender.ToNonAD().String(),
+			Type:       receiptType,
+			Timestamp:  e.Timestamp,
+		})
+		database.DB.Model(&models.Message{}).
+			Where("instance_id = ? AND message_id = ? AND from_me = ?", instance.ID, id, true).
+			Where("status != ?", models.MessageStatusRead).
+			Update("status", newStatus)
+		if e.Chat.Server == types.GroupServer {
+			ic.updateGroupDeliverySummary(instance, e.Chat, id)
+		}
+		instance.signalDelivery(id)
+	}
+}
+
+// updateGroupDeliverySummary recomputes the aggregated receipt counts of
+// one outgoing group message. The participant count is resolved from the
+// group info the first time a receipt arrives, best effort.
+func (ic *InstanceController) updateGroupDeliverySummary(instance *Instance, chat types.JID, messageID string) {
+	var sent int64
+	database.DB.Model(&models.Message{}).
+		Where("instance_id = ? AND message_id = ? AND from_me = ?", instance.ID, messageID, true).
+		Count(&sent)
+	if sent == 0 {
+		return
+	}
+
+	var delivered, read int64
+	database.DB.Model(&models.MessageReceipt{}).
+		Where("instance_id = ? AND message_id = ?", instance.ID, messageID).
+		Distinct("sender_jid").
+		Count(&delivered)
+	database.DB.Model(&models.MessageReceipt{}).
+		Where("instance_id = ? AND message_id = ? AND type IN ?", instance.ID, messageID, []string{"read", "played"}).
+		Distinct("sender_jid").
+		Count(&read)
+
+	var summary models.GroupDeliveryStatus
+	err := database.DB.
+		Where("instance_id = ? AND message_id = ?", instance.ID, messageID).
+		First(&summary).Error
+	if err != nil {
+		summary = models.GroupDeliveryStatus{
+			InstanceID:     instance.ID,
+			MessageID:      messageID,
+			GroupJID:       chat.String(),
+			DeliveredCount: int(delivered),
+			ReadCount:      int(read),
+		}
+		if info, err := instance.Client.GetGroupInfo(chat); err == nil {
+			// The sending device does not receipt its own message.
+			summary.TotalParticipants = len(info.Participants) - 1
+		}
+		database.DB.Create(&summary)
+		return
+	}
+	database.DB.Model(&summary).Updates(map[string]interface{}{
+		"delivered_count": delivered,
+		"read_count":      read,
+	})
+}
@@ -0,0 +1,113 @@
+package controllers
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/store"
+	"go.mau.fi/whatsmeow/types/events"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/Layoxd/whatsapiGo/src/database"
+	"github.com/Layoxd/whatsapiGo/src/services"
+)
+
+// TestInstanceMapConcurrentAccess hammers the instances map accessors from
+// many goroutines at once. It exists to be run under -race: the map itself
+// carries no assertions beyond not corrupting its own count.
+func TestInstanceMapConcurrentAccess(t *testing.T) {
+	ic := NewInstanceController(nil)
+
+	const workers = 16
+	const iterations = 200
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < iterations; i++ {
+				id := fmt.Sprintf("instance-%d-%d", w, i)
+				ic.addInstance(&Instance{ID: id, Name: id})
+				if _, ok := ic.getInstance(id); !ok {
+					t.Errorf("instance %s missing right after add", id)
+				}
+				ic.instanceCount()
+				for range ic.snapshotInstances() {
+					break
+				}
+				ic.removeInstance(id)
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	if count := ic.instanceCount(); count != 0 {
+		t.Errorf("expected empty map after balanced add/remove, got %d", count)
+	}
+}
+
+// TestInstanceRuntimeStateConcurrentAccess runs a real event worker while
+// HTTP-handler-style goroutines read and update the same instance. Like
+// the map test it exists for -race: the worker writes status, connect
+// time and QR state while other goroutines build the detail view, record
+// session errors and toggle the keepalive.
+func TestInstanceRuntimeStateConcurrentAccess(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("opening in-memory database: %v", err)
+	}
+	if err := db.AutoMigrate(database.MigratedModels()...); err != nil {
+		t.Fatalf("migrating: %v", err)
+	}
+	restoreDB := database.DB
+	database.DB = db
+	defer func() { database.DB = restoreDB }()
+
+	ic := NewInstanceController(services.NewWebhookService(db))
+	instance := &Instance{
+		ID:     "inst-race",
+		Name:   "race",
+		Client: &whatsmeow.Client{Store: &store.Device{}},
+	}
+	ic.setupEventHandlers(instance)
+	ic.addInstance(instance)
+	defer ic.stopEventWorker(instance)
+
+	const iterations = 100
+	var wg sync.WaitGroup
+	wg.Add(4)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < iterations; i++ {
+			instance.events <- &events.Connected{}
+			instance.events <- &events.Disconnected{}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < iterations; i++ {
+			ic.instanceDetail(instance)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < iterations; i++ {
+			ic.recordSessionError(instance, fmt.Sprintf("error %d", i))
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < iterations; i++ {
+			ic.startKeepalive(instance)
+			ic.stopKeepalive(instance)
+		}
+	}()
+	wg.Wait()
+}
@@ -0,0 +1,291 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/Layoxd/whatsapiGo/src/database"
+	"github.com/Layoxd/whatsapiGo/src/models"
+	"github.com/Layoxd/whatsapiGo/src/utils"
+)
+
+// defaultBulkDelay paces bulk sends when the request does not choose a
+// delay of its own.
+const defaultBulkDelay = time.Second
+
+// JobController runs bulk and scheduled sends as background jobs and
+// exposes their progress. Job rows are persisted; the cancel channels
+// only exist for jobs owned by this process.
+type JobController struct {
+	instanceController *InstanceController
+	messageController  *MessageController
+
+	mu      sync.Mutex
+	cancels map[string]chan struct{}
+}
+
+// NewJobController creates the job controller, marks jobs that were
+// mid-flight during the last shutdown as interrupted and re-arms
+// schedules that are still pending.
+func NewJobController(ic *InstanceController, mc *MessageController) *JobController {
+	jc := &JobController{
+		instanceController: ic,
+		messageController:  mc,
+		cancels:            make(map[string]chan struct{}),
+	}
+	database.DB.Model(&models.SendJob{}).
+		Where("status = ?", models.JobStatusRunning).
+		Update("status", models.JobStatusInterrupted)
+	jc.rearmSchedules()
+	return jc
+}
+
+// rearmSchedules restarts the timer for every scheduled job that is
+// still pending, including ones whose send time passed while the
+// process was down.
+func (jc *JobController) rearmSchedules() {
+	var jobs []models.SendJob
+	if err := database.DB.
+		Where("type = ? AND status = ?", "scheduled", models.JobStatusPending).
+		Find(&jobs).Error; err != nil {
+		return
+	}
+	for i := range jobs {
+		job := jobs[i]
+		var req models.ScheduleTextMessageRequest
+		if err := json.Unmarshal([]byte(job.Payload), &req); err != nil || job.ScheduledAt == nil {
+			jc.updateJob(job.ID, map[string]interface{}{"status": models.JobStatusFailed})
+			continue
+		}
+		go jc.runScheduledJob(job.ID, &req, *job.ScheduledAt, jc.registerCancel(job.ID))
+	}
+}
+
+// registerCancel creates the in-memory cancel channel for a job.
+func (jc *JobController) registerCancel(jobID string) chan struct{} {
+	cancel := make(chan struct{})
+	jc.mu.Lock()
+	jc.cancels[jobID] = cancel
+	jc.mu.Unlock()
+	return cancel
+}
+
+// releaseCancel drops the cancel channel once a job has finished.
+func (jc *JobController) releaseCancel(jobID string) {
+	jc.mu.Lock()
+	delete(jc.cancels, jobID)
+	jc.mu.Unlock()
+}
+
+// updateJob applies field updates to a persisted job row.
+func (jc *JobController) updateJob(jobID string, updates map[string]interface{}) {
+	database.DB.Model(&models.SendJob{}).Where("id = ?", jobID).Updates(updates)
+}
+
+// SendBulkText starts a background job sending the same text to many
+// recipients and returns the job ID for progress polling.
+func (jc *JobController) SendBulkText(c *gin.Context) {
+	var req models.SendBulkTextRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	instance, ok := jc.messageController.getConnectedInstance(c, req.InstanceID)
+	if !ok {
+		return
+	}
+
+	payload, _ := json.Marshal(req)
+	job := models.SendJob{
+		ID:         uuid.NewString(),
+		InstanceID: instance.ID,
+		Type:       "bulk",
+		Status:     models.JobStatusRunning,
+		Total:      len(req.Phones),
+		Payload:    string(payload),
+	}
+	if err := database.DB.Create(&job).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	go jc.runBulkJob(instance, job.ID, &req, jc.registerCancel(job.ID))
+	c.JSON(http.StatusAccepted, gin.H{"success": true, "data": gin.H{
+		"job_id": job.ID,
+		"total":  job.Total,
+		"status": job.Status,
+	}})
+}
+
+// runBulkJob works through the recipient list, updating progress after
+// every send and stopping early when the job is cancelled.
+func (jc *JobController) runBulkJob(instance *Instance, jobID string, req *models.SendBulkTextRequest, cancel chan struct{}) {
+	defer jc.releaseCancel(jobID)
+	delay := defaultBulkDelay
+	if req.DelayMs > 0 {
+		delay = time.Duration(req.DelayMs) * time.Millisecond
+	}
+
+	sent, failed := 0, 0
+	for i, phone := range req.Phones {
+		if i > 0 {
+			select {
+			case <-cancel:
+				jc.updateJob(jobID, map[string]interface{}{"status": models.JobStatusCancelled})
+				return
+			case <-time.After(delay):
+			}
+		}
+		jid := utils.UserJID(phone)
+		ctx, cancelCtx := sendContext(req.TimeoutSeconds)
+		resp, err := instance.Client.SendMessage(ctx, jid, &waE2E.Message{Conversation: proto.String(req.Message)})
+		cancelCtx()
+		if err != nil {
+			failed++
+		} else {
+			sent++
+			jc.messageController.recordOutgoingMessage(instance, resp, jid, "text", req.Message, nil)
+		}
+		jc.updateJob(jobID, map[string]interface{}{"sent": sent, "failed": failed})
+	}
+	status := models.JobStatusCompleted
+	if sent == 0 && failed > 0 {
+		status = models.JobStatusFailed
+	}
+	jc.updateJob(jobID, map[string]interface{}{"status": status})
+}
+
+// ScheduleText queues a text message for a later send time.
+func (jc *JobController) ScheduleText(c *gin.Context) {
+	var req models.ScheduleTextMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	sendAt, err := time.Parse(time.RFC3339, req.SendAt)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "send_at must be an RFC 3339 timestamp"})
+		return
+	}
+	if !sendAt.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "send_at must be in the future"})
+		return
+	}
+	if _, ok := jc.instanceController.getInstance(req.InstanceID); !ok {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "instance not found"})
+		return
+	}
+
+	payload, _ := json.Marshal(req)
+	job := models.SendJob{
+		ID:          uuid.NewString(),
+		InstanceID:  req.InstanceID,
+		Type:        "scheduled",
+		Status:      models.JobStatusPending,
+		Total:       1,
+		Payload:     string(payload),
+		ScheduledAt: &sendAt,
+	}
+	if err := database.DB.Create(&job).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	go jc.runScheduledJob(job.ID, &req, sendAt, jc.registerCancel(job.ID))
+	c.JSON(http.StatusAccepted, gin.H{"success": true, "data": gin.H{
+		"job_id":       job.ID,
+		"status":       job.Status,
+		"scheduled_at": sendAt,
+	}})
+}
+
+// runScheduledJob waits for the send time and delivers the message; the
+// instance only needs to be connected at fire time.
+func (jc *JobController) runScheduledJob(jobID string, req *models.ScheduleTextMessageRequest, sendAt time.Time, cancel chan struct{}) {
+	defer jc.releaseCancel(jobID)
+	timer := time.NewTimer(time.Until(sendAt))
+	defer timer.Stop()
+	select {
+	case <-cancel:
+		jc.updateJob(jobID, map[string]interface{}{"status": models.JobStatusCancelled})
+		return
+	case <-timer.C:
+	}
+
+	jc.updateJob(jobID, map[string]interface{}{"status": models.JobStatusRunning})
+	instance, ok := jc.instanceController.getInstance(req.InstanceID)
+	if !ok || !instance.Client.IsLoggedIn() {
+		jc.updateJob(jobID, map[string]interface{}{"status": models.JobStatusFailed, "failed": 1})
+		return
+	}
+	jid := jc.messageController.buildRecipientJID(req.Phone, req.IsGroup)
+	ctx, cancelCtx := sendContext(req.TimeoutSeconds)
+	defer cancelCtx()
+	resp, err := instance.Client.SendMessage(ctx, jid, &waE2E.Message{Conversation: proto.String(req.Message)})
+	if err != nil {
+		jc.updateJob(jobID, map[string]interface{}{"status": models.JobStatusFailed, "failed": 1})
+		return
+	}
+	jc.messageController.recordOutgoingMessage(instance, resp, jid, "text", req.Message, nil)
+	jc.updateJob(jobID, map[string]interface{}{"status": models.JobStatusCompleted, "sent": 1})
+}
+
+// GetJobs lists the bulk and scheduled jobs of an instance, newest
+// first. An optional ?status= filters the list.
+func (jc *JobController) GetJobs(c *gin.Context) {
+	instanceID := c.Param("instanceId")
+	if _, ok := jc.instanceController.getInstance(instanceID); !ok {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "instance not found"})
+		return
+	}
+	query := database.DB.Where("instance_id = ?", instanceID)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var jobs []models.SendJob
+	if err := query.Order("created_at DESC").Find(&jobs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": jobs})
+}
+
+// CancelJob stops a running bulk send or a pending schedule.
+func (jc *JobController) CancelJob(c *gin.Context) {
+	instanceID := c.Param("instanceId")
+	if _, ok := jc.instanceController.getInstance(instanceID); !ok {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "instance not found"})
+		return
+	}
+	var job models.SendJob
+	err := database.DB.
+		Where("instance_id = ? AND id = ?", instanceID, c.Param("jobId")).
+		First(&job).Error
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "job not found"})
+		return
+	}
+	if job.Status != models.JobStatusPending && job.Status != models.JobStatusRunning {
+		c.JSON(http.StatusConflict, gin.H{"success": false, "error": "job is already " + job.Status})
+		return
+	}
+
+	jc.mu.Lock()
+	cancel, owned := jc.cancels[job.ID]
+	if owned {
+		delete(jc.cancels, job.ID)
+	}
+	jc.mu.Unlock()
+	if owned {
+		close(cancel)
+	} else {
+		// No worker in this process (e.g. a stale row); just mark it.
+		jc.updateJob(job.ID, map[string]interface{}{"status": models.JobStatusCancelled})
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "job cancelled"})
+}
@@ -0,0 +1,161 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mau.fi/whatsmeow/appstate"
+	"go.mau.fi/whatsmeow/types"
+
+	"github.com/Layoxd/whatsapiGo/src/database"
+	"github.com/Layoxd/whatsapiGo/src/models"
+)
+
+// LabelController manages business labels: the label catalog and the
+// label-to-chat assignments, both synced through WhatsApp app state.
+type LabelController struct {
+	instanceController *InstanceController
+}
+
+// NewLabelController creates the label controller.
+func NewLabelController(ic *InstanceController) *LabelController {
+	return &LabelController{instanceController: ic}
+}
+
+// getConnectedInstance mirrors the message controller helper.
+func (lc *LabelController) getConnectedInstance(c *gin.Context, instanceID string) (*Instance, bool) {
+	instance, ok := lc.instanceController.getInstance(instanceID)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "instance not found"})
+		return nil, false
+	}
+	if !instance.Client.IsLoggedIn() {
+		c.JSON(http.StatusConflict, gin.H{"success": false, "error": "instance is not connected"})
+		return nil, false
+	}
+	return instance, true
+}
+
+// WhatsApp offers a fixed palette of 20 label colors, addressed by index.
+const maxLabelColor = 19
+
+// GetLabels lists the labels known for an instance.
+func (lc *LabelController) GetLabels(c *gin.Context) {
+	instanceID := c.Param("instanceId")
+	if _, ok := lc.instanceController.getInstance(instanceID); !ok {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "instance not found"})
+		return
+	}
+	var labels []models.Label
+	if err := database.DB.
+		Where("instance_id = ? AND deleted = ?", instanceID, false).
+		Order("name ASC").
+		Find(&labels).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": labels})
+}
+
+// CreateLabel creates a label and syncs it to the account's app state.
+func (lc *LabelController) CreateLabel(c *gin.Context) {
+	var req struct {
+		Name  string `json:"name" binding:"required"`
+		Color int32  `json:"color"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	if req.Color < 0 || req.Color > maxLabelColor {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "color must be between 0 and 19"})
+		return
+	}
+	instance, ok := lc.getConnectedInstance(c, c.Param("instanceId"))
+	if !ok {
+		return
+	}
+
+	labelID := strconv.FormatInt(time.Now().UnixMilli(), 10)
+	if err := instance.Client.SendAppState(appstate.BuildLabelEdit(labelID, req.Name, req.Color, false)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	label := models.Label{
+		InstanceID: instance.ID,
+		LabelID:    labelID,
+		Name:       req.Name,
+		Color:      req.Color,
+	}
+	database.DB.Create(&label)
+	c.JSON(http.StatusCreated, gin.H{"success": true, "data": label})
+}
+
+// AssignLabel puts a label on a chat.
+func (lc *LabelController) AssignLabel(c *gin.Context) {
+	lc.setChatLabel(c, true)
+}
+
+// UnassignLabel removes a label from a chat.
+func (lc *LabelController) UnassignLabel(c *gin.Context) {
+	lc.setChatLabel(c, false)
+}
+
+// setChatLabel applies one labeled/unlabeled change via app state and
+// mirrors it into the chat_labels table.
+func (lc *LabelController) setChatLabel(c *gin.Context, labeled bool) {
+	var req struct {
+		ChatJID string `json:"chat_jid" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	chat, err := types.ParseJID(req.ChatJID)
+	if err != nil || chat.User == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "invalid chat_jid"})
+		return
+	}
+	instance, ok := lc.getConnectedInstance(c, c.Param("instanceId"))
+	if !ok {
+		return
+	}
+	labelID := c.Param("labelId")
+	var label models.Label
+	err = database.DB.
+		Where("instance_id = ? AND label_id = ? AND deleted = ?", instance.ID, labelID, false).
+		First(&label).Error
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "label not found"})
+		return
+	}
+
+	if err := instance.Client.SendAppState(appstate.BuildLabelChat(chat, labelID, labeled)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	if labeled {
+		var existing models.ChatLabel
+		err := database.DB.
+			Where("instance_id = ? AND chat_jid = ? AND label_id = ?", instance.ID, chat.String(), labelID).
+			First(&existing).Error
+		if err != nil {
+			database.DB.Create(&models.ChatLabel{
+				InstanceID: instance.ID,
+				ChatJID:    chat.String(),
+				LabelID:    labelID,
+			})
+		}
+	} else {
+		database.DB.
+			Where("instance_id = ? AND chat_jid = ? AND label_id = ?", instance.ID, chat.String(), labelID).
+			Delete(&models.ChatLabel{})
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{
+		"label_id": labelID,
+		"chat_jid": chat.String(),
+		"labeled":  labeled,
+	}})
+}
@@ -0,0 +1,54 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Layoxd/whatsapiGo/src/database"
+	"github.com/Layoxd/whatsapiGo/src/models"
+	"github.com/Layoxd/whatsapiGo/src/utils"
+)
+
+// LogController exposes the processed-event log.
+type LogController struct{}
+
+// NewLogController creates the log controller.
+func NewLogController() *LogController {
+	return &LogController{}
+}
+
+// GetLogs lists event log entries, filterable by instance and event type.
+func (lc *LogController) GetLogs(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+
+	query := database.DB.Model(&models.EventLog{})
+	if instanceID := c.Query("instance_id"); instanceID != "" {
+		query = query.Where("instance_id = ?", instanceID)
+	}
+	if eventType := c.Query("event_type"); eventType != "" {
+		query = query.Where("event_type = ?", eventType)
+	}
+
+	var total int64
+	query.Count(&total)
+	pagination := utils.NewPagination(page, limit, total)
+
+	var logs []models.EventLog
+	if err := query.
+		Order("created_at DESC").
+		Offset(pagination.Offset()).
+		Limit(pagination.Limit).
+		Find(&logs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":    true,
+		"data":       logs,
+		"pagination": pagination,
+	})
+}
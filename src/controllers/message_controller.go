@@ -0,0 +1,1808 @@
+package controllers
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/appstate"
+	"go.mau.fi/whatsmeow/proto/waCommon"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/proto/waSyncAction"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+	"gorm.io/gorm"
+
+	"github.com/Layoxd/whatsapiGo/src/config"
+	"github.com/Layoxd/whatsapiGo/src/database"
+	"github.com/Layoxd/whatsapiGo/src/models"
+	"github.com/Layoxd/whatsapiGo/src/utils"
+)
+
+// MessageController handles sending messages and message-level actions.
+type MessageController struct {
+	instanceController *InstanceController
+
+	// presenceMu guards sentPresence, the in-memory record of the last
+	// typing/recording state sent per chat, keyed instanceID:chatJID.
+	presenceMu   sync.Mutex
+	sentPresence map[string]sentPresence
+}
+
+// sentPresence is the last outgoing chat presence for one chat.
+type sentPresence struct {
+	State  string    `json:"state"`
+	Media  string    `json:"media,omitempty"`
+	SentAt time.Time `json:"sent_at"`
+}
+
+// NewMessageController creates the message controller.
+func NewMessageController(ic *InstanceController) *MessageController {
+	return &MessageController{
+		instanceController: ic,
+		sentPresence:       make(map[string]sentPresence),
+	}
+}
+
+// buildRecipientJID converts a phone number or group ID into the JID a
+// message should be addressed to.
+func (mc *MessageController) buildRecipientJID(phone string, isGroup bool) types.JID {
+	if isGroup {
+		return utils.GroupJID(phone)
+	}
+	return utils.UserJID(phone)
+}
+
+// resolveRecipient returns the JID a send is addressed to. A raw
+// recipient_jid wins when present — parsed and validated as-is so user,
+// group, newsletter or LID JIDs can all be targeted uniformly — otherwise
+// phone and is_group go through the usual formatting. Errors are written
+// to the response.
+func (mc *MessageController) resolveRecipient(c *gin.Context, recipientJID, phone string, isGroup bool) (types.JID, bool) {
+	if recipientJID != "" {
+		jid, err := types.ParseJID(recipientJID)
+		if err != nil || jid.User == "" || jid.Server == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "invalid recipient_jid"})
+			return types.EmptyJID, false
+		}
+		return jid, true
+	}
+	if phone == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "phone or recipient_jid is required"})
+		return types.EmptyJID, false
+	}
+	return mc.buildRecipientJID(phone, isGroup), true
+}
+
+// getConnectedInstance fetches the instance and writes the error response
+// itself when it is missing or not connected.
+func (mc *MessageController) getConnectedInstance(c *gin.Context, instanceID string) (*Instance, bool) {
+	instance, ok := mc.instanceController.getInstance(instanceID)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "instance not found"})
+		return nil, false
+	}
+	if !instance.Client.IsLoggedIn() {
+		c.JSON(http.StatusConflict, gin.H{"success": false, "error": "instance is not connected"})
+		return nil, false
+	}
+	return instance, true
+}
+
+// recordOutgoingMessage stores a message sent through the API.
+func (mc *MessageController) recordOutgoingMessage(instance *Instance, resp whatsmeow.SendResponse, chat types.JID, msgType, content string, media *utils.MediaBundle) {
+	msg := models.Message{
+		InstanceID: instance.ID,
+		MessageID:  resp.ID,
+		ChatJID:    chat.String(),
+		FromMe:     true,
+		Type:       msgType,
+		Content:    content,
+		Status:     models.MessageStatusSent,
+		Timestamp:  resp.Timestamp,
+	}
+	if instance.Client.Store.ID != nil {
+		msg.SenderJID = instance.Client.Store.ID.ToNonAD().String()
+	}
+	if media != nil {
+		msg.MediaURL = media.URL
+		msg.DirectPath = media.DirectPath
+		msg.MediaKey = media.MediaKey
+		msg.FileEncSHA256 = media.FileEncSHA256
+		msg.FileSHA256 = media.FileSHA256
+		msg.FileLength = media.FileLength
+		msg.Mimetype = media.Mimetype
+	}
+	database.DB.Create(&msg)
+}
+
+// sendContext bounds an outgoing send with the configured timeout; a
+// per-request override takes precedence and a non-positive result leaves
+// the send unbounded.
+func sendContext(overrideSeconds int) (context.Context, context.CancelFunc) {
+	seconds := config.Get().SendTimeoutSeconds
+	if overrideSeconds > 0 {
+		seconds = overrideSeconds
+	}
+	if seconds <= 0 {
+		return context.Background(), func() {}
+	}
+	return context.WithTimeout(context.Background(), time.Duration(seconds)*time.Second)
+}
+
+// sendErrorStatus maps a send error to an HTTP status; timeouts become
+// 504 so callers can tell a stuck send apart from a rejected one.
+func sendErrorStatus(err error) int {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return http.StatusGatewayTimeout
+	}
+	return http.StatusInternalServerError
+}
+
+// sentResponse is the common success payload for send endpoints.
+func sentResponse(resp whatsmeow.SendResponse, chat types.JID) gin.H {
+	return gin.H{
+		"message_id": resp.ID,
+		"chat_jid":   chat.String(),
+		"timestamp":  resp.Timestamp,
+	}
+}
+
+// defaultWaitDeliverySeconds bounds wait_delivery when the request does
+// not set its own wait timeout.
+const defaultWaitDeliverySeconds = 10
+
+// allowedEphemeralSeconds are the per-message expirations WhatsApp
+// accepts, the same durations as the chat-level disappearing timers.
+var allowedEphemeralSeconds = map[uint32]bool{
+	uint32(whatsmeow.DisappearingTimer24Hours.Seconds()): true,
+	uint32(whatsmeow.DisappearingTimer7Days.Seconds()):   true,
+	uint32(whatsmeow.DisappearingTimer90Days.Seconds()):  true,
+}
+
+// validateEphemeralSeconds checks a per-message expiration against the
+// durations WhatsApp accepts, writing the error response on failure. Zero
+// (no expiration) is valid.
+func validateEphemeralSeconds(c *gin.Context, seconds uint32) bool {
+	if seconds == 0 || allowedEphemeralSeconds[seconds] {
+		return true
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "ephemeral_seconds must be 86400 (24h), 604800 (7d) or 7776000 (90d)"})
+	return false
+}
+
+// applyEphemeral sets the per-message expiration on a media message's
+// context info, creating it when absent.
+func applyEphemeral(msg *waE2E.Message, seconds uint32) {
+	if seconds == 0 {
+		return
+	}
+	expiration := proto.Uint32(seconds)
+	switch {
+	case msg.ImageMessage != nil:
+		if msg.ImageMessage.ContextInfo == nil {
+			msg.ImageMessage.ContextInfo = &waE2E.ContextInfo{}
+		}
+		msg.ImageMessage.ContextInfo.Expiration = expiration
+	case msg.VideoMessage != nil:
+		if msg.VideoMessage.ContextInfo == nil {
+			msg.VideoMessage.ContextInfo = &waE2E.ContextInfo{}
+		}
+		msg.VideoMessage.ContextInfo.Expiration = expiration
+	case msg.AudioMessage != nil:
+		if msg.AudioMessage.ContextInfo == nil {
+			msg.AudioMessage.ContextInfo = &waE2E.ContextInfo{}
+		}
+		msg.AudioMessage.ContextInfo.Expiration = expiration
+	case msg.DocumentMessage != nil:
+		if msg.DocumentMessage.ContextInfo == nil {
+			msg.DocumentMessage.ContextInfo = &waE2E.ContextInfo{}
+		}
+		msg.DocumentMessage.ContextInfo.Expiration = expiration
+	}
+}
+
+// buildExternalAdReply converts an ad-context request into the protobuf
+// external ad reply attached to an outgoing message's context info.
+func buildExternalAdReply(req *models.AdContextRequest) (*waE2E.ContextInfo_ExternalAdReplyInfo, error) {
+	adReply := &waE2E.ContextInfo_ExternalAdReplyInfo{}
+	switch req.MediaType {
+	case "":
+	case "image":
+		adReply.MediaType = waE2E.ContextInfo_ExternalAdReplyInfo_IMAGE.Enum()
+	case "video":
+		adReply.MediaType = waE2E.ContextInfo_ExternalAdReplyInfo_VIDEO.Enum()
+	default:
+		return nil, errors.New("ad_context media_type must be image or video")
+	}
+	if req.Title != "" {
+		adReply.Title = proto.String(req.Title)
+	}
+	if req.Body != "" {
+		adReply.Body = proto.String(req.Body)
+	}
+	if req.ThumbnailURL != "" {
+		adReply.ThumbnailURL = proto.String(req.ThumbnailURL)
+	}
+	if req.MediaURL != "" {
+		adReply.MediaURL = proto.String(req.MediaURL)
+	}
+	if req.SourceType != "" {
+		adReply.SourceType = proto.String(req.SourceType)
+	}
+	if req.SourceID != "" {
+		adReply.SourceID = proto.String(req.SourceID)
+	}
+	if req.SourceURL != "" {
+		adReply.SourceURL = proto.String(req.SourceURL)
+	}
+	if req.CtwaClid != "" {
+		adReply.CtwaClid = proto.String(req.CtwaClid)
+	}
+	if req.Ref != "" {
+		adReply.Ref = proto.String(req.Ref)
+	}
+	return adReply, nil
+}
+
+// clientMessageIDPattern matches caller-supplied message IDs: uppercase
+// hex, the shape WhatsApp clients generate and accept.
+var clientMessageIDPattern = regexp.MustCompile(`^[0-9A-F]{16,64}$`)
+
+// clientMessageIDTTL is the window within which a caller-supplied message
+// ID must be unique per instance.
+const clientMessageIDTTL = 24 * time.Hour
+
+// validateClientMessageID checks the format of a caller-supplied message
+// ID and that it was not used for this instance within the TTL. Errors
+// are written to the response.
+func validateClientMessageID(c *gin.Context, instanceID, id string) bool {
+	if !clientMessageIDPattern.MatchString(id) {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "client_message_id must be 16-64 uppercase hex characters"})
+		return false
+	}
+	var count int64
+	database.DB.Model(&models.Message{}).
+		Where("instance_id = ? AND message_id = ? AND created_at > ?", instanceID, id, time.Now().Add(-clientMessageIDTTL)).
+		Count(&count)
+	if count > 0 {
+		c.JSON(http.StatusConflict, gin.H{"success": false, "error": "client_message_id was already used within the last 24 hours"})
+		return false
+	}
+	return true
+}
+
+// sendRetryBackoff is the base delay between transient-send retry
+// attempts; attempt n waits n times this.
+const sendRetryBackoff = 500 * time.Millisecond
+
+// transientSendError reports whether a send failure is worth retrying:
+// connection-level errors that a reconnect resolves, as opposed to
+// permanent rejections (bad recipient, unsupported server) or the
+// caller's own timeout.
+func transientSendError(err error) bool {
+	if err == nil || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return false
+	}
+	return errors.Is(err, whatsmeow.ErrNotConnected) ||
+		errors.Is(err, whatsmeow.ErrIQTimedOut) ||
+		errors.Is(err, whatsmeow.ErrMessageTimedOut) ||
+		errors.Is(err, whatsmeow.ErrIQDisconnected)
+}
+
+// retryEnabled resolves a request's retry_transient override against the
+// config default.
+func retryEnabled(override *bool) bool {
+	if override != nil {
+		return *override
+	}
+	return config.Get().SendRetryTransient
+}
+
+// sendWithRetry sends the message, retrying transient failures with a
+// linear backoff when retry is enabled. The send context still bounds the
+// whole sequence, so retries never outlive the caller's timeout.
+func sendWithRetry(ctx context.Context, instance *Instance, jid types.JID, msg *waE2E.Message, retry bool, extra ...whatsmeow.SendRequestExtra) (whatsmeow.SendResponse, error) {
+	resp, err := instance.Client.SendMessage(ctx, jid, msg, extra...)
+	if !retry {
+		return resp, err
+	}
+	for attempt := 1; attempt <= config.Get().SendMaxRetries && transientSendError(err); attempt++ {
+		select {
+		case <-ctx.Done():
+			return resp, err
+		case <-time.After(time.Duration(attempt) * sendRetryBackoff):
+		}
+		resp, err = instance.Client.SendMessage(ctx, jid, msg, extra...)
+	}
+	return resp, err
+}
+
+// sendWithOptionalWait sends the message and, when wait is set, blocks
+// until the delivered receipt arrives or the wait times out. The
+// returned delivery status is "delivered" or "sent" ("" when no wait was
+// requested); a timeout is not an error since the message went out. A
+// non-empty customID becomes the WhatsApp message ID of the send.
+func sendWithOptionalWait(ctx context.Context, instance *Instance, jid types.JID, msg *waE2E.Message, customID string, wait bool, waitSeconds int, retry bool) (whatsmeow.SendResponse, string, error) {
+	if !wait {
+		if customID != "" {
+			resp, err := sendWithRetry(ctx, instance, jid, msg, retry, whatsmeow.SendRequestExtra{ID: customID})
+			return resp, "", err
+		}
+		resp, err := sendWithRetry(ctx, instance, jid, msg, retry)
+		return resp, "", err
+	}
+	// The waiter must exist before the send: the receipt can arrive
+	// before SendMessage returns.
+	id := customID
+	if id == "" {
+		id = instance.Client.GenerateMessageID()
+	}
+	waiter := instance.registerDeliveryWaiter(id)
+	resp, err := sendWithRetry(ctx, instance, jid, msg, retry, whatsmeow.SendRequestExtra{ID: id})
+	if err != nil {
+		instance.removeDeliveryWaiter(id)
+		return resp, "", err
+	}
+	if waitSeconds <= 0 {
+		waitSeconds = defaultWaitDeliverySeconds
+	}
+	select {
+	case <-waiter:
+		return resp, models.MessageStatusDelivered, nil
+	case <-time.After(time.Duration(waitSeconds) * time.Second):
+		instance.removeDeliveryWaiter(id)
+		return resp, models.MessageStatusSent, nil
+	}
+}
+
+// quoteContext builds the ContextInfo for a reply. Same-chat quotes keep
+// the old behavior; a quotedChat pointing at a different chat becomes a
+// cross-chat quote with RemoteJID set so the client resolves the
+// reference. The participant comes from the stored message when the API
+// has it, else from quotedSender — which is mandatory for quoting out of
+// a group the store does not know, since the group JID alone cannot
+// identify the author.
+func quoteContext(instance *Instance, target types.JID, messageID, quotedChat, quotedSender string) (*waE2E.ContextInfo, error) {
+	participant := ""
+	content := ""
+	var stored models.Message
+	if err := database.DB.Where("instance_id = ? AND message_id = ?", instance.ID, messageID).First(&stored).Error; err == nil {
+		participant = stored.SenderJID
+		content = stored.Content
+		if quotedChat == "" && stored.ChatJID != "" {
+			quotedChat = stored.ChatJID
+		}
+	}
+	if quotedSender != "" {
+		sender, err := types.ParseJID(quotedSender)
+		if err != nil || sender.User == "" {
+			return nil, errors.New("invalid reply_to_sender")
+		}
+		participant = sender.ToNonAD().String()
+	}
+	remote := target
+	if quotedChat != "" {
+		chat, err := types.ParseJID(quotedChat)
+		if err != nil || chat.User == "" || chat.Server == "" {
+			return nil, errors.New("invalid reply_to_chat")
+		}
+		remote = chat
+	}
+	if participant == "" {
+		switch {
+		case remote.Server != types.GroupServer:
+			// In a 1:1 chat the author can only be the other side.
+			participant = remote.ToNonAD().String()
+		case remote == target:
+			participant = target.String()
+		default:
+			return nil, errors.New("reply_to_sender is required when quoting a message from another group")
+		}
+	}
+	contextInfo := &waE2E.ContextInfo{
+		StanzaID:      proto.String(messageID),
+		Participant:   proto.String(participant),
+		QuotedMessage: &waE2E.Message{Conversation: proto.String(content)},
+	}
+	if remote.String() != target.String() {
+		contextInfo.RemoteJID = proto.String(remote.String())
+	}
+	return contextInfo, nil
+}
+
+// SendTextMessage sends a plain text message, optionally quoting an
+// earlier message in the same chat.
+func (mc *MessageController) SendTextMessage(c *gin.Context) {
+	var req models.SendTextMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	text, err := resolveTextContent(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	instance, ok := mc.getConnectedInstance(c, req.InstanceID)
+	if !ok {
+		return
+	}
+	if req.ClientMessageID != "" && !validateClientMessageID(c, instance.ID, req.ClientMessageID) {
+		return
+	}
+	if !validateEphemeralSeconds(c, req.EphemeralSeconds) {
+		return
+	}
+	jid, ok := mc.resolveRecipient(c, req.RecipientJID, req.Phone, req.IsGroup)
+	if !ok {
+		return
+	}
+
+	var mentionedJIDs []string
+	if req.MentionAll {
+		mentioned, tokens, ok := mc.mentionAllContext(c, instance, jid)
+		if !ok {
+			return
+		}
+		mentionedJIDs = mentioned
+		if tokens != "" {
+			text += "\n" + tokens
+		}
+	}
+
+	var contextInfo *waE2E.ContextInfo
+	if req.ReplyTo != "" {
+		var err error
+		contextInfo, err = quoteContext(instance, jid, req.ReplyTo, req.ReplyToChat, req.ReplyToSender)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+			return
+		}
+	}
+	if len(mentionedJIDs) > 0 {
+		if contextInfo == nil {
+			contextInfo = &waE2E.ContextInfo{}
+		}
+		contextInfo.MentionedJID = mentionedJIDs
+	}
+	if req.EphemeralSeconds > 0 {
+		if contextInfo == nil {
+			contextInfo = &waE2E.ContextInfo{}
+		}
+		contextInfo.Expiration = proto.Uint32(req.EphemeralSeconds)
+	}
+	if req.AdContext != nil {
+		adReply, err := buildExternalAdReply(req.AdContext)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+			return
+		}
+		if contextInfo == nil {
+			contextInfo = &waE2E.ContextInfo{}
+		}
+		contextInfo.ExternalAdReply = adReply
+	}
+	var msg *waE2E.Message
+	if contextInfo != nil {
+		msg = &waE2E.Message{ExtendedTextMessage: &waE2E.ExtendedTextMessage{
+			Text:        proto.String(text),
+			ContextInfo: contextInfo,
+		}}
+	} else {
+		msg = &waE2E.Message{Conversation: proto.String(text)}
+	}
+
+	ctx, cancel := sendContext(req.TimeoutSeconds)
+	defer cancel()
+	resp, deliveryStatus, err := sendWithOptionalWait(ctx, instance, jid, msg, req.ClientMessageID, req.WaitDelivery, req.WaitDeliverySeconds, retryEnabled(req.RetryTransient))
+	if err != nil {
+		c.JSON(sendErrorStatus(err), gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	mc.recordOutgoingMessage(instance, resp, jid, "text", text, nil)
+	data := sentResponse(resp, jid)
+	if req.Format != "" || len(req.Segments) > 0 {
+		data["rendered_text"] = text
+	}
+	if deliveryStatus != "" {
+		data["delivery_status"] = deliveryStatus
+	}
+	if req.MentionAll {
+		data["mentioned_count"] = len(mentionedJIDs)
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": data})
+}
+
+// maxMentionAllParticipants caps mention-everyone sends; beyond this the
+// token list alone makes the message unusably long.
+const maxMentionAllParticipants = 256
+
+// mentionAllContext fetches the group roster for a mention-everyone send
+// and returns the participant JIDs to mention plus the @-tokens to append
+// to the text. The sender itself is left out. It enforces the group-size
+// cap and, for announcement groups, that this account is an admin; errors
+// are written to the response.
+func (mc *MessageController) mentionAllContext(c *gin.Context, instance *Instance, group types.JID) ([]string, string, bool) {
+	if group.Server != types.GroupServer {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "mention_all requires a group recipient"})
+		return nil, "", false
+	}
+	info, err := instance.Client.GetGroupInfo(group)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "fetching group info: " + err.Error()})
+		return nil, "", false
+	}
+	if len(info.Participants) > maxMentionAllParticipants {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   fmt.Sprintf("group has %d participants, mention_all is capped at %d", len(info.Participants), maxMentionAllParticipants),
+		})
+		return nil, "", false
+	}
+	var self types.JID
+	if instance.Client.Store.ID != nil {
+		self = instance.Client.Store.ID.ToNonAD()
+	}
+	isAdmin := false
+	mentioned := make([]string, 0, len(info.Participants))
+	var tokens strings.Builder
+	for _, p := range info.Participants {
+		if p.JID.User == self.User {
+			isAdmin = p.IsAdmin || p.IsSuperAdmin
+			continue
+		}
+		mentioned = append(mentioned, p.JID.ToNonAD().String())
+		if tokens.Len() > 0 {
+			tokens.WriteByte(' ')
+		}
+		tokens.WriteString("@")
+		tokens.WriteString(p.JID.User)
+	}
+	if info.IsAnnounce && !isAdmin {
+		c.JSON(http.StatusForbidden, gin.H{"success": false, "error": "only admins can send to an announcement group"})
+		return nil, "", false
+	}
+	return mentioned, tokens.String(), true
+}
+
+// SendTemplateMessage renders a stored template with the request's
+// parameter values and sends it through the normal text pipeline.
+func (mc *MessageController) SendTemplateMessage(c *gin.Context) {
+	var req models.SendTemplateMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	instance, ok := mc.getConnectedInstance(c, req.InstanceID)
+	if !ok {
+		return
+	}
+	var template models.MessageTemplate
+	err := database.DB.
+		Where("instance_id = ? AND id = ?", instance.ID, req.TemplateID).
+		First(&template).Error
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "template not found"})
+		return
+	}
+	text, err := utils.RenderTemplate(template.Content, req.Params)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	jid, ok := mc.resolveRecipient(c, req.RecipientJID, req.Phone, req.IsGroup)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := sendContext(req.TimeoutSeconds)
+	defer cancel()
+	resp, err := instance.Client.SendMessage(ctx, jid, &waE2E.Message{Conversation: proto.String(text)})
+	if err != nil {
+		c.JSON(sendErrorStatus(err), gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	mc.recordOutgoingMessage(instance, resp, jid, "text", text, nil)
+	data := sentResponse(resp, jid)
+	data["template_id"] = template.ID
+	data["rendered_text"] = text
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": data})
+}
+
+// resolveTextContent produces the final message text for a send-text
+// request: segments are rendered into WhatsApp markdown, and the
+// "markdown" format mode validates that formatting markers are balanced.
+func resolveTextContent(req *models.SendTextMessageRequest) (string, error) {
+	text := req.Message
+	if len(req.Segments) > 0 {
+		var b strings.Builder
+		for _, seg := range req.Segments {
+			wrapped, err := utils.WrapStyles(seg.Text, seg.Styles)
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(wrapped)
+		}
+		text = b.String()
+	}
+	if text == "" {
+		return "", errors.New("message or segments is required")
+	}
+	switch req.Format {
+	case "":
+	case "markdown":
+		if err := utils.ValidateMarkdown(text); err != nil {
+			return "", err
+		}
+	default:
+		return "", errors.New("format must be markdown")
+	}
+	return text, nil
+}
+
+// SendMediaMessage sends an image, video, audio or document message from a
+// base64 payload.
+func (mc *MessageController) SendMediaMessage(c *gin.Context) {
+	var req models.SendMediaMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	instance, ok := mc.getConnectedInstance(c, req.InstanceID)
+	if !ok {
+		return
+	}
+	mc.sendMediaMessage(c, instance, &req)
+}
+
+// sendMediaMessage uploads the media and sends the right message type for
+// the mimetype.
+func (mc *MessageController) sendMediaMessage(c *gin.Context, instance *Instance, req *models.SendMediaMessageRequest) {
+	data, err := base64.StdEncoding.DecodeString(req.MediaBase64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "invalid base64 media"})
+		return
+	}
+	if req.IsPTT {
+		if err := utils.ValidateVoiceNote(data); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+			return
+		}
+	}
+	if req.ClientMessageID != "" && !validateClientMessageID(c, instance.ID, req.ClientMessageID) {
+		return
+	}
+	if !validateEphemeralSeconds(c, req.EphemeralSeconds) {
+		return
+	}
+	jid, ok := mc.resolveRecipient(c, req.RecipientJID, req.Phone, req.IsGroup)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := sendContext(req.TimeoutSeconds)
+	defer cancel()
+
+	mediaType, msgType := mediaTypeForMimetype(req.Mimetype)
+	uploaded, err := instance.Client.Upload(ctx, data, mediaType)
+	if err != nil {
+		c.JSON(sendErrorStatus(err), gin.H{"success": false, "error": "upload failed: " + err.Error()})
+		return
+	}
+
+	msg := buildMediaMessage(msgType, req, &uploaded, uint64(len(data)))
+	applyEphemeral(msg, req.EphemeralSeconds)
+	resp, deliveryStatus, err := sendWithOptionalWait(ctx, instance, jid, msg, req.ClientMessageID, req.WaitDelivery, req.WaitDeliverySeconds, retryEnabled(req.RetryTransient))
+	if err != nil {
+		c.JSON(sendErrorStatus(err), gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	mc.recordOutgoingMessage(instance, resp, jid, msgType, req.Caption, utils.MediaInfo(msg))
+	response := sentResponse(resp, jid)
+	if deliveryStatus != "" {
+		response["delivery_status"] = deliveryStatus
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": response})
+}
+
+// mediaTypeForMimetype maps a mimetype onto the whatsmeow media type and
+// the public message type name.
+func mediaTypeForMimetype(mimetype string) (whatsmeow.MediaType, string) {
+	switch {
+	case len(mimetype) >= 5 && mimetype[:5] == "image":
+		return whatsmeow.MediaImage, "image"
+	case len(mimetype) >= 5 && mimetype[:5] == "video":
+		return whatsmeow.MediaVideo, "video"
+	case len(mimetype) >= 5 && mimetype[:5] == "audio":
+		return whatsmeow.MediaAudio, "audio"
+	default:
+		return whatsmeow.MediaDocument, "document"
+	}
+}
+
+// buildMediaMessage assembles the media message proto from an upload.
+func buildMediaMessage(msgType string, req *models.SendMediaMessageRequest, uploaded *whatsmeow.UploadResponse, length uint64) *waE2E.Message {
+	switch msgType {
+	case "image":
+		return &waE2E.Message{ImageMessage: &waE2E.ImageMessage{
+			Caption:       proto.String(req.Caption),
+			Mimetype:      proto.String(req.Mimetype),
+			URL:           &uploaded.URL,
+			DirectPath:    &uploaded.DirectPath,
+			MediaKey:      uploaded.MediaKey,
+			FileEncSHA256: uploaded.FileEncSHA256,
+			FileSHA256:    uploaded.FileSHA256,
+			FileLength:    proto.Uint64(length),
+		}}
+	case "video":
+		return &waE2E.Message{VideoMessage: &waE2E.VideoMessage{
+			Caption:       proto.String(req.Caption),
+			Mimetype:      proto.String(req.Mimetype),
+			URL:           &uploaded.URL,
+			DirectPath:    &uploaded.DirectPath,
+			MediaKey:      uploaded.MediaKey,
+			FileEncSHA256: uploaded.FileEncSHA256,
+			FileSHA256:    uploaded.FileSHA256,
+			FileLength:    proto.Uint64(length),
+		}}
+	case "audio":
+		return &waE2E.Message{AudioMessage: &waE2E.AudioMessage{
+			Mimetype:      proto.String(req.Mimetype),
+			PTT:           proto.Bool(req.IsPTT),
+			URL:           &uploaded.URL,
+			DirectPath:    &uploaded.DirectPath,
+			MediaKey:      uploaded.MediaKey,
+			FileEncSHA256: uploaded.FileEncSHA256,
+			FileSHA256:    uploaded.FileSHA256,
+			FileLength:    proto.Uint64(length),
+		}}
+	default:
+		fileName := req.FileName
+		if fileName == "" {
+			fileName = "document"
+		}
+		return &waE2E.Message{DocumentMessage: &waE2E.DocumentMessage{
+			Caption:       proto.String(req.Caption),
+			FileName:      proto.String(fileName),
+			Mimetype:      proto.String(req.Mimetype),
+			URL:           &uploaded.URL,
+			DirectPath:    &uploaded.DirectPath,
+			MediaKey:      uploaded.MediaKey,
+			FileEncSHA256: uploaded.FileEncSHA256,
+			FileSHA256:    uploaded.FileSHA256,
+			FileLength:    proto.Uint64(length),
+		}}
+	}
+}
+
+// Album size limits WhatsApp applies client-side.
+const (
+	minAlbumItems = 2
+	maxAlbumItems = 30
+)
+
+// SendAlbum sends several images/videos grouped as one album: an album
+// parent message followed by the media messages associated to it, so
+// clients render them together.
+func (mc *MessageController) SendAlbum(c *gin.Context) {
+	var req models.SendAlbumRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	if len(req.Items) < minAlbumItems || len(req.Items) > maxAlbumItems {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   fmt.Sprintf("albums need between %d and %d items", minAlbumItems, maxAlbumItems),
+		})
+		return
+	}
+	payloads := make([][]byte, len(req.Items))
+	for i, item := range req.Items {
+		if !strings.HasPrefix(item.Mimetype, "image/") && !strings.HasPrefix(item.Mimetype, "video/") {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "album items must be image or video"})
+			return
+		}
+		data, err := base64.StdEncoding.DecodeString(item.MediaBase64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": fmt.Sprintf("item %d: invalid base64 media", i)})
+			return
+		}
+		payloads[i] = data
+	}
+	instance, ok := mc.getConnectedInstance(c, req.InstanceID)
+	if !ok {
+		return
+	}
+	jid, ok := mc.resolveRecipient(c, req.RecipientJID, req.Phone, req.IsGroup)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := sendContext(req.TimeoutSeconds)
+	defer cancel()
+
+	albumResp, err := instance.Client.SendMessage(ctx, jid, &waE2E.Message{
+		AlbumMessage: &waE2E.AlbumMessage{Caption: proto.String(req.Caption)},
+	})
+	if err != nil {
+		c.JSON(sendErrorStatus(err), gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	parentKey := &waCommon.MessageKey{
+		RemoteJID: proto.String(jid.String()),
+		FromMe:    proto.Bool(true),
+		ID:        proto.String(albumResp.ID),
+	}
+
+	messageIDs := make([]string, 0, len(req.Items))
+	for i, item := range req.Items {
+		mediaType, msgType := mediaTypeForMimetype(item.Mimetype)
+		uploaded, err := instance.Client.Upload(ctx, payloads[i], mediaType)
+		if err != nil {
+			c.JSON(sendErrorStatus(err), gin.H{"success": false, "error": fmt.Sprintf("item %d: upload failed: %s", i, err)})
+			return
+		}
+		msg := buildMediaMessage(msgType, &models.SendMediaMessageRequest{
+			Caption:  item.Caption,
+			Mimetype: item.Mimetype,
+		}, &uploaded, uint64(len(payloads[i])))
+		msg.MessageContextInfo = &waE2E.MessageContextInfo{
+			MessageAssociation: &waE2E.MessageAssociation{
+				AssociationType:  waE2E.MessageAssociation_MEDIA_ALBUM.Enum(),
+				ParentMessageKey: parentKey,
+			},
+		}
+		resp, err := instance.Client.SendMessage(ctx, jid, msg)
+		if err != nil {
+			c.JSON(sendErrorStatus(err), gin.H{"success": false, "error": fmt.Sprintf("item %d: %s", i, err)})
+			return
+		}
+		mc.recordOutgoingMessage(instance, resp, jid, msgType, item.Caption, utils.MediaInfo(msg))
+		messageIDs = append(messageIDs, resp.ID)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{
+		"album_id":    albumResp.ID,
+		"message_ids": messageIDs,
+		"chat_jid":    jid.String(),
+		"timestamp":   albumResp.Timestamp,
+	}})
+}
+
+// SendLocationMessage sends a static location pin.
+func (mc *MessageController) SendLocationMessage(c *gin.Context) {
+	var req models.SendLocationMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	instance, ok := mc.getConnectedInstance(c, req.InstanceID)
+	if !ok {
+		return
+	}
+	jid, ok := mc.resolveRecipient(c, req.RecipientJID, req.Phone, req.IsGroup)
+	if !ok {
+		return
+	}
+	msg := &waE2E.Message{LocationMessage: &waE2E.LocationMessage{
+		DegreesLatitude:  proto.Float64(req.Latitude),
+		DegreesLongitude: proto.Float64(req.Longitude),
+		Name:             proto.String(req.Name),
+		Address:          proto.String(req.Address),
+	}}
+	ctx, cancel := sendContext(req.TimeoutSeconds)
+	defer cancel()
+	resp, err := instance.Client.SendMessage(ctx, jid, msg)
+	if err != nil {
+		c.JSON(sendErrorStatus(err), gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	mc.recordOutgoingMessage(instance, resp, jid, "location", req.Name, nil)
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": sentResponse(resp, jid)})
+}
+
+// Live location duration bounds WhatsApp accepts (15 minutes to 8 hours).
+const (
+	minLiveLocationSeconds = 15 * 60
+	maxLiveLocationSeconds = 8 * 60 * 60
+)
+
+// SendLiveLocationMessage starts a live-location share. The initial
+// message carries sequence number 0; clients then follow the share for
+// the given duration.
+func (mc *MessageController) SendLiveLocationMessage(c *gin.Context) {
+	var req models.SendLiveLocationMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	if req.DurationSeconds < minLiveLocationSeconds || req.DurationSeconds > maxLiveLocationSeconds {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   fmt.Sprintf("duration_seconds must be between %d and %d", minLiveLocationSeconds, maxLiveLocationSeconds),
+		})
+		return
+	}
+	instance, ok := mc.getConnectedInstance(c, req.InstanceID)
+	if !ok {
+		return
+	}
+	jid, ok := mc.resolveRecipient(c, req.RecipientJID, req.Phone, req.IsGroup)
+	if !ok {
+		return
+	}
+	// LiveLocationMessage has no share-duration field; the validated
+	// duration is only echoed in the response for the caller to track.
+	msg := &waE2E.Message{LiveLocationMessage: &waE2E.LiveLocationMessage{
+		DegreesLatitude:  proto.Float64(req.Latitude),
+		DegreesLongitude: proto.Float64(req.Longitude),
+		Caption:          proto.String(req.Caption),
+		SequenceNumber:   proto.Int64(0),
+	}}
+	ctx, cancel := sendContext(req.TimeoutSeconds)
+	defer cancel()
+	resp, err := instance.Client.SendMessage(ctx, jid, msg)
+	if err != nil {
+		c.JSON(sendErrorStatus(err), gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	mc.recordOutgoingMessage(instance, resp, jid, "live_location", req.Caption, nil)
+	data := sentResponse(resp, jid)
+	data["duration_seconds"] = req.DurationSeconds
+	data["sequence_number"] = 0
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": data})
+}
+
+// SendContactMessage shares a contact card.
+func (mc *MessageController) SendContactMessage(c *gin.Context) {
+	var req models.SendContactMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	instance, ok := mc.getConnectedInstance(c, req.InstanceID)
+	if !ok {
+		return
+	}
+	jid, ok := mc.resolveRecipient(c, req.RecipientJID, req.Phone, req.IsGroup)
+	if !ok {
+		return
+	}
+	contactPhone := utils.NormalizePhone(req.ContactPhone)
+	vcard := fmt.Sprintf(
+		"BEGIN:VCARD\nVERSION:3.0\nFN:%s\nTEL;type=CELL;waid=%s:+%s\nEND:VCARD",
+		req.ContactName, contactPhone, contactPhone,
+	)
+	msg := &waE2E.Message{ContactMessage: &waE2E.ContactMessage{
+		DisplayName: proto.String(req.ContactName),
+		Vcard:       proto.String(vcard),
+	}}
+	ctx, cancel := sendContext(req.TimeoutSeconds)
+	defer cancel()
+	resp, err := instance.Client.SendMessage(ctx, jid, msg)
+	if err != nil {
+		c.JSON(sendErrorStatus(err), gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	mc.recordOutgoingMessage(instance, resp, jid, "contact", req.ContactName, nil)
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": sentResponse(resp, jid)})
+}
+
+// SendPoll sends a poll message, storing its options so later votes can
+// be decoded and aggregated by the poll-results endpoint.
+func (mc *MessageController) SendPoll(c *gin.Context) {
+	var req models.SendPollRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	instance, ok := mc.getConnectedInstance(c, req.InstanceID)
+	if !ok {
+		return
+	}
+	jid, ok := mc.resolveRecipient(c, req.RecipientJID, req.Phone, req.IsGroup)
+	if !ok {
+		return
+	}
+	seen := make(map[string]bool, len(req.Options))
+	for _, option := range req.Options {
+		if option == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "poll options must not be empty"})
+			return
+		}
+		if seen[option] {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "poll options must be unique"})
+			return
+		}
+		seen[option] = true
+	}
+	if req.SelectableCount < 0 || req.SelectableCount > len(req.Options) {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "selectable_count must be between 0 and the number of options"})
+		return
+	}
+
+	msg := instance.Client.BuildPollCreation(req.Question, req.Options, req.SelectableCount)
+	ctx, cancel := sendContext(req.TimeoutSeconds)
+	defer cancel()
+	resp, err := instance.Client.SendMessage(ctx, jid, msg)
+	if err != nil {
+		c.JSON(sendErrorStatus(err), gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	options, _ := json.Marshal(req.Options)
+	database.DB.Create(&models.Poll{
+		InstanceID:      instance.ID,
+		MessageID:       resp.ID,
+		ChatJID:         jid.String(),
+		Question:        req.Question,
+		Options:         string(options),
+		SelectableCount: req.SelectableCount,
+	})
+	mc.recordOutgoingMessage(instance, resp, jid, "poll", req.Question, nil)
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": sentResponse(resp, jid)})
+}
+
+// GetPollResults aggregates the stored votes of a poll sent through the
+// API into per-option counts and voter lists. Multi-select votes count
+// once per selected option; a voter's newest vote replaces older ones.
+func (mc *MessageController) GetPollResults(c *gin.Context) {
+	instanceID := c.Param("instanceId")
+	messageID := c.Param("messageId")
+	if _, ok := mc.instanceController.getInstance(instanceID); !ok {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "instance not found"})
+		return
+	}
+	var poll models.Poll
+	if err := database.DB.Where("instance_id = ? AND message_id = ?", instanceID, messageID).First(&poll).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "poll not found"})
+		return
+	}
+	var options []string
+	_ = json.Unmarshal([]byte(poll.Options), &options)
+
+	var votes []models.PollVote
+	if err := database.DB.
+		Where("instance_id = ? AND poll_message_id = ?", instanceID, messageID).
+		Find(&votes).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	counts := make(map[string]int, len(options))
+	voters := make(map[string][]string, len(options))
+	for _, option := range options {
+		voters[option] = []string{}
+	}
+	totalVotes, totalVoters := 0, 0
+	for _, vote := range votes {
+		var selected []string
+		if err := json.Unmarshal([]byte(vote.SelectedOptions), &selected); err != nil {
+			continue
+		}
+		if len(selected) == 0 {
+			// Retracted vote: the voter unselected everything.
+			continue
+		}
+		totalVoters++
+		for _, option := range selected {
+			counts[option]++
+			voters[option] = append(voters[option], vote.VoterJID)
+			totalVotes++
+		}
+	}
+	results := make([]gin.H, 0, len(options))
+	for _, option := range options {
+		results = append(results, gin.H{
+			"option": option,
+			"votes":  counts[option],
+			"voters": voters[option],
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{
+		"message_id":       messageID,
+		"chat_jid":         poll.ChatJID,
+		"question":         poll.Question,
+		"selectable_count": poll.SelectableCount,
+		"multi_select":     poll.SelectableCount > 1,
+		"total_votes":      totalVotes,
+		"total_voters":     totalVoters,
+		"results":          results,
+	}})
+}
+
+// SendRequestPhoneNumber sends a request-phone-number message, prompting
+// the recipient to share their number. Groups are not valid recipients.
+func (mc *MessageController) SendRequestPhoneNumber(c *gin.Context) {
+	var req models.RequestPhoneNumberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	instance, ok := mc.getConnectedInstance(c, req.InstanceID)
+	if !ok {
+		return
+	}
+	jid := utils.UserJID(req.Phone)
+	if jid.User == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "invalid phone number"})
+		return
+	}
+	msg := &waE2E.Message{RequestPhoneNumberMessage: &waE2E.RequestPhoneNumberMessage{
+		ContextInfo: &waE2E.ContextInfo{},
+	}}
+	ctx, cancel := sendContext(req.TimeoutSeconds)
+	defer cancel()
+	resp, err := instance.Client.SendMessage(ctx, jid, msg)
+	if err != nil {
+		c.JSON(sendErrorStatus(err), gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	mc.recordOutgoingMessage(instance, resp, jid, "request_phone_number", "", nil)
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": sentResponse(resp, jid)})
+}
+
+// SendReaction reacts to an existing message; an empty emoji removes the
+// reaction.
+func (mc *MessageController) SendReaction(c *gin.Context) {
+	var req models.SendReactionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	instance, ok := mc.getConnectedInstance(c, req.InstanceID)
+	if !ok {
+		return
+	}
+	chat, ok := mc.resolveRecipient(c, req.RecipientJID, req.Phone, req.IsGroup)
+	if !ok {
+		return
+	}
+
+	// The reaction key needs the original sender; prefer the stored copy.
+	sender := chat
+	var stored models.Message
+	if err := database.DB.Where("instance_id = ? AND message_id = ?", instance.ID, req.MessageID).First(&stored).Error; err == nil {
+		if stored.FromMe && instance.Client.Store.ID != nil {
+			sender = instance.Client.Store.ID.ToNonAD()
+		} else if parsed, err := types.ParseJID(stored.SenderJID); err == nil {
+			sender = parsed
+		}
+	}
+
+	msg := instance.Client.BuildReaction(chat, sender, req.MessageID, req.Emoji)
+	ctx, cancel := sendContext(req.TimeoutSeconds)
+	defer cancel()
+	resp, err := instance.Client.SendMessage(ctx, chat, msg)
+	if err != nil {
+		c.JSON(sendErrorStatus(err), gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": sentResponse(resp, chat)})
+}
+
+// Pin durations WhatsApp offers in its UI.
+var allowedPinDurations = map[uint32]bool{
+	24 * 60 * 60:      true,
+	7 * 24 * 60 * 60:  true,
+	30 * 24 * 60 * 60: true,
+}
+
+// pinMessage sends the pin-in-chat protocol message for a stored message.
+func (mc *MessageController) pinMessage(c *gin.Context, pin bool) {
+	var req models.PinMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	if pin {
+		if req.DurationSeconds == 0 {
+			req.DurationSeconds = 7 * 24 * 60 * 60
+		}
+		if !allowedPinDurations[req.DurationSeconds] {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "duration_seconds must be 24h, 7d or 30d in seconds"})
+			return
+		}
+	}
+	instance, ok := mc.getConnectedInstance(c, req.InstanceID)
+	if !ok {
+		return
+	}
+	chat := mc.buildRecipientJID(req.Chat, req.IsGroup)
+
+	key := &waCommon.MessageKey{
+		RemoteJID: proto.String(chat.String()),
+		ID:        proto.String(req.MessageID),
+		FromMe:    proto.Bool(false),
+	}
+	var stored models.Message
+	if err := database.DB.Where("instance_id = ? AND message_id = ?", instance.ID, req.MessageID).First(&stored).Error; err == nil {
+		key.FromMe = proto.Bool(stored.FromMe)
+		if req.IsGroup && !stored.FromMe {
+			key.Participant = proto.String(stored.SenderJID)
+		}
+	}
+
+	pinType := waE2E.PinInChatMessage_UNPIN_FOR_ALL
+	if pin {
+		pinType = waE2E.PinInChatMessage_PIN_FOR_ALL
+	}
+	msg := &waE2E.Message{
+		PinInChatMessage: &waE2E.PinInChatMessage{
+			Key:               key,
+			Type:              pinType.Enum(),
+			SenderTimestampMS: proto.Int64(time.Now().UnixMilli()),
+		},
+		MessageContextInfo: &waE2E.MessageContextInfo{
+			MessageAddOnDurationInSecs: proto.Uint32(req.DurationSeconds),
+		},
+	}
+	ctx, cancel := sendContext(req.TimeoutSeconds)
+	defer cancel()
+	resp, err := instance.Client.SendMessage(ctx, chat, msg)
+	if err != nil {
+		c.JSON(sendErrorStatus(err), gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	action := "unpinned"
+	if pin {
+		action = "pinned"
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "message " + action, "data": sentResponse(resp, chat)})
+}
+
+// PinMessage pins a message in a chat.
+func (mc *MessageController) PinMessage(c *gin.Context) {
+	mc.pinMessage(c, true)
+}
+
+// UnpinMessage removes a pin from a chat.
+func (mc *MessageController) UnpinMessage(c *gin.Context) {
+	mc.pinMessage(c, false)
+}
+
+// StarMessage stars or unstars a message via an app-state mutation. The
+// message must exist in the local store so the mutation index can be
+// built with the right sender and direction.
+func (mc *MessageController) StarMessage(c *gin.Context) {
+	var req models.StarMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	chat, err := types.ParseJID(req.Chat)
+	if err != nil || chat.User == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "invalid chat"})
+		return
+	}
+	instance, ok := mc.getConnectedInstance(c, req.InstanceID)
+	if !ok {
+		return
+	}
+	var msg models.Message
+	err = database.DB.
+		Where("instance_id = ? AND chat_jid = ? AND message_id = ?", instance.ID, chat.String(), req.MessageID).
+		First(&msg).Error
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "message not found in local store"})
+		return
+	}
+
+	sender := types.EmptyJID
+	if chat.Server == types.GroupServer && !msg.FromMe {
+		sender, _ = types.ParseJID(msg.SenderJID)
+	}
+	if err := instance.Client.SendAppState(buildStarPatch(chat, sender, req.MessageID, msg.FromMe, *req.Starred)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	database.DB.Model(&msg).Update("starred", *req.Starred)
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{
+		"chat":       chat.String(),
+		"message_id": req.MessageID,
+		"starred":    *req.Starred,
+	}})
+}
+
+// buildStarPatch builds the app-state star mutation; whatsmeow has no
+// builder for it at this version, so the index layout mirrors the one its
+// app-state decoder expects.
+func buildStarPatch(chat, sender types.JID, messageID string, fromMe, starred bool) appstate.PatchInfo {
+	isFromMe := "0"
+	if fromMe {
+		isFromMe = "1"
+	}
+	senderID := "0"
+	if chat.Server == types.GroupServer && !sender.IsEmpty() {
+		senderID = sender.ToNonAD().String()
+	}
+	return appstate.PatchInfo{
+		Type: appstate.WAPatchRegularHigh,
+		Mutations: []appstate.MutationInfo{{
+			Index:   []string{appstate.IndexStar, chat.String(), messageID, isFromMe, senderID},
+			Version: 2,
+			Value: &waSyncAction.SyncActionValue{
+				StarAction: &waSyncAction.StarAction{
+					Starred: proto.Bool(starred),
+				},
+			},
+		}},
+	}
+}
+
+// MarkRead marks messages in a chat as read.
+func (mc *MessageController) MarkRead(c *gin.Context) {
+	var req models.MarkReadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	instance, ok := mc.getConnectedInstance(c, req.InstanceID)
+	if !ok {
+		return
+	}
+	chat := mc.buildRecipientJID(req.Phone, req.IsGroup)
+	sender := chat
+	if req.SenderJID != "" {
+		parsed, err := types.ParseJID(req.SenderJID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "invalid sender_jid"})
+			return
+		}
+		sender = parsed
+	}
+	ids := make([]types.MessageID, 0, len(req.MessageIDs))
+	for _, id := range req.MessageIDs {
+		ids = append(ids, id)
+	}
+	if err := instance.Client.MarkRead(ids, time.Now(), chat, sender); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	response := gin.H{"success": true, "message": "marked as read"}
+	// With read receipts disabled the receipt is still sent but senders
+	// never see blue ticks; surface that so callers are not surprised.
+	var row models.Instance
+	if err := database.DB.Select("read_receipts_disabled").First(&row, "id = ?", instance.ID).Error; err == nil && row.ReadReceiptsDisabled {
+		response["warning"] = "read receipts are disabled for this account; senders will not see blue ticks"
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// SendChatPresence sends a typing/recording indicator.
+func (mc *MessageController) SendChatPresence(c *gin.Context) {
+	var req models.ChatPresenceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	instance, ok := mc.getConnectedInstance(c, req.InstanceID)
+	if !ok {
+		return
+	}
+	var state types.ChatPresence
+	switch req.State {
+	case "composing":
+		state = types.ChatPresenceComposing
+	case "paused":
+		state = types.ChatPresencePaused
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "state must be composing or paused"})
+		return
+	}
+	media := types.ChatPresenceMediaText
+	if req.Media == "audio" {
+		media = types.ChatPresenceMediaAudio
+	}
+	chat := mc.buildRecipientJID(req.Phone, req.IsGroup)
+	if err := instance.Client.SendChatPresence(chat, state, media); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	mc.recordSentPresence(instance.ID, chat, req.State, req.Media)
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "presence sent"})
+}
+
+// recordSentPresence remembers the last presence sent to a chat; paused
+// clears the record since the chat shows nothing afterwards.
+func (mc *MessageController) recordSentPresence(instanceID string, chat types.JID, state, media string) {
+	key := instanceID + ":" + chat.String()
+	mc.presenceMu.Lock()
+	defer mc.presenceMu.Unlock()
+	if state == "paused" {
+		delete(mc.sentPresence, key)
+		return
+	}
+	mc.sentPresence[key] = sentPresence{State: state, Media: media, SentAt: time.Now()}
+}
+
+// presenceChatParam parses the :chat path parameter, writing the error
+// response on failure.
+func presenceChatParam(c *gin.Context) (types.JID, bool) {
+	chat, err := types.ParseJID(c.Param("chat"))
+	if err != nil || chat.User == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "invalid chat path parameter"})
+		return types.EmptyJID, false
+	}
+	return chat, true
+}
+
+// GetChatPresenceState reports the last typing/recording state this API
+// sent to a chat, so integrations can tell whether an indicator is still
+// hanging.
+func (mc *MessageController) GetChatPresenceState(c *gin.Context) {
+	instanceID := c.Param("instanceId")
+	if _, ok := mc.instanceController.getInstance(instanceID); !ok {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "instance not found"})
+		return
+	}
+	chat, ok := presenceChatParam(c)
+	if !ok {
+		return
+	}
+	mc.presenceMu.Lock()
+	state, active := mc.sentPresence[instanceID+":"+chat.String()]
+	mc.presenceMu.Unlock()
+	data := gin.H{"chat_jid": chat.String(), "active": active}
+	if active {
+		data["presence"] = state
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": data})
+}
+
+// ClearChatPresenceState clears a hanging typing/recording indicator by
+// sending paused, whether or not one is tracked; the tracked state is
+// dropped either way.
+func (mc *MessageController) ClearChatPresenceState(c *gin.Context) {
+	instanceID := c.Param("instanceId")
+	instance, ok := mc.getConnectedInstance(c, instanceID)
+	if !ok {
+		return
+	}
+	chat, ok := presenceChatParam(c)
+	if !ok {
+		return
+	}
+	key := instanceID + ":" + chat.String()
+	mc.presenceMu.Lock()
+	state, wasActive := mc.sentPresence[key]
+	delete(mc.sentPresence, key)
+	mc.presenceMu.Unlock()
+
+	media := types.ChatPresenceMediaText
+	if state.Media == "audio" {
+		media = types.ChatPresenceMediaAudio
+	}
+	if err := instance.Client.SendChatPresence(chat, types.ChatPresencePaused, media); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{
+		"chat_jid":   chat.String(),
+		"was_active": wasActive,
+	}})
+}
+
+// GetMessageReadBy lists the group participants that have read a message,
+// derived from the stored read receipts. Returns an empty list when no
+// read receipts have arrived yet.
+func (mc *MessageController) GetMessageReadBy(c *gin.Context) {
+	instanceID := c.Param("instanceId")
+	messageID := c.Param("messageId")
+	if _, ok := mc.instanceController.getInstance(instanceID); !ok {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "instance not found"})
+		return
+	}
+
+	var stored models.Message
+	err := database.DB.Where("instance_id = ? AND message_id = ?", instanceID, messageID).First(&stored).Error
+	if err == nil && !strings.HasSuffix(stored.ChatJID, "@"+types.GroupServer) {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "message is not a group message"})
+		return
+	}
+
+	var receipts []models.MessageReceipt
+	if err := database.DB.
+		Where("instance_id = ? AND message_id = ? AND type = ?", instanceID, messageID, "read").
+		Order("timestamp ASC").
+		Find(&receipts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	// One entry per participant; keep the earliest read time.
+	readBy := make([]gin.H, 0, len(receipts))
+	seen := make(map[string]bool, len(receipts))
+	for _, r := range receipts {
+		if seen[r.SenderJID] {
+			continue
+		}
+		seen[r.SenderJID] = true
+		readBy = append(readBy, gin.H{"jid": r.SenderJID, "read_at": r.Timestamp})
+	}
+
+	data := gin.H{
+		"message_id": messageID,
+		"read_count": len(readBy),
+		"read_by":    readBy,
+	}
+	if err == nil {
+		data["chat_jid"] = stored.ChatJID
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": data})
+}
+
+// RevokeMessage deletes a message for everyone in the chat using the
+// revoke protocol, the same mechanism deleteOwnStatus uses for statuses.
+// Without sender_jid the instance's own message is revoked; with it, a
+// group admin can revoke someone else's message — WhatsApp rejects the
+// revoke server-side if the instance is not an admin of the group.
+func (mc *MessageController) RevokeMessage(c *gin.Context) {
+	var req models.RevokeMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	instance, ok := mc.getConnectedInstance(c, req.InstanceID)
+	if !ok {
+		return
+	}
+	jid, ok := mc.resolveRecipient(c, req.RecipientJID, req.Phone, req.IsGroup)
+	if !ok {
+		return
+	}
+	sender, err := revokeSender(instance, jid, req.SenderJID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	revoke := instance.Client.BuildRevoke(jid, sender, req.MessageID)
+	ctx, cancel := sendContext(0)
+	defer cancel()
+	resp, err := instance.Client.SendMessage(ctx, jid, revoke)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	database.DB.Model(&models.Message{}).
+		Where("instance_id = ? AND message_id = ?", req.InstanceID, req.MessageID).
+		Update("status", "revoked")
+	scope := "own"
+	if req.SenderJID != "" {
+		scope = "admin"
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{
+		"message_id": req.MessageID,
+		"revoked":    true,
+		"scope":      scope,
+		"timestamp":  resp.Timestamp,
+	}})
+}
+
+// revokeSender resolves whose message a revoke targets. Empty sender_jid
+// means the instance's own message; a non-empty one is only meaningful
+// for group chats, where admins may revoke other participants' messages.
+func revokeSender(instance *Instance, chat types.JID, senderJID string) (types.JID, error) {
+	if senderJID == "" {
+		if instance.Client.Store.ID == nil {
+			return types.EmptyJID, errors.New("instance has no JID")
+		}
+		return instance.Client.Store.ID.ToNonAD(), nil
+	}
+	if chat.Server != types.GroupServer {
+		return types.EmptyJID, errors.New("sender_jid is only valid for group chats; own messages are revoked without it")
+	}
+	sender, err := types.ParseJID(senderJID)
+	if err != nil || sender.User == "" {
+		return types.EmptyJID, errors.New("invalid sender_jid")
+	}
+	return sender.ToNonAD(), nil
+}
+
+// GetMessageHistory returns an instance's stored messages, newest first,
+// optionally filtered to one chat and a time range. Sent and received
+// messages are both persisted, so history covers both directions.
+func (mc *MessageController) GetMessageHistory(c *gin.Context) {
+	var req models.MessageHistoryRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	chatJID := ""
+	if req.Phone != "" {
+		chatJID = mc.buildRecipientJID(req.Phone, req.IsGroup).String()
+	}
+	history, err := queryMessageHistory(database.DB, c.Param("instanceId"), chatJID, &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": history})
+}
+
+// queryMessageHistory applies the history filters against the message
+// table. Limit defaults to 50 and is capped at 200; timestamps are
+// RFC3339.
+func queryMessageHistory(db *gorm.DB, instanceID, chatJID string, req *models.MessageHistoryRequest) (*models.MessageHistoryResponse, error) {
+	limit := req.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+	if limit > 200 {
+		limit = 200
+	}
+	offset := req.Offset
+	if offset < 0 {
+		offset = 0
+	}
+
+	query := db.Model(&models.Message{}).Where("instance_id = ?", instanceID)
+	if chatJID != "" {
+		query = query.Where("chat_jid = ?", chatJID)
+	}
+	if req.FromTime != "" {
+		from, err := time.Parse(time.RFC3339, req.FromTime)
+		if err != nil {
+			return nil, errors.New("from_time must be RFC3339")
+		}
+		query = query.Where("timestamp >= ?", from)
+	}
+	if req.ToTime != "" {
+		to, err := time.Parse(time.RFC3339, req.ToTime)
+		if err != nil {
+			return nil, errors.New("to_time must be RFC3339")
+		}
+		query = query.Where("timestamp <= ?", to)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, err
+	}
+	var rows []models.Message
+	if err := query.Order("timestamp DESC").Limit(limit).Offset(offset).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	messages := make([]models.SentMessageInfo, 0, len(rows))
+	for _, row := range rows {
+		messages = append(messages, models.SentMessageInfo{
+			MessageID: row.MessageID,
+			ChatJID:   row.ChatJID,
+			SenderJID: row.SenderJID,
+			FromMe:    row.FromMe,
+			Type:      row.Type,
+			Content:   row.Content,
+			Status:    row.Status,
+			Timestamp: row.Timestamp,
+		})
+	}
+	return &models.MessageHistoryResponse{
+		Messages: messages,
+		Total:    total,
+		Limit:    limit,
+		Offset:   offset,
+	}, nil
+}
+
+// GetGroupDeliveryStatus returns the aggregated delivery summary of an
+// outgoing group message, maintained from incoming receipts.
+func (mc *MessageController) GetGroupDeliveryStatus(c *gin.Context) {
+	instanceID := c.Param("instanceId")
+	messageID := c.Param("messageId")
+	if _, ok := mc.instanceController.getInstance(instanceID); !ok {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "instance not found"})
+		return
+	}
+	var summary models.GroupDeliveryStatus
+	err := database.DB.
+		Where("instance_id = ? AND message_id = ?", instanceID, messageID).
+		First(&summary).Error
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "no delivery summary for this message yet"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": summary})
+}
+
+// GetMessage returns the stored record of a single message: content,
+// media metadata, direction and status. 404 means the message was never
+// persisted (or predates this instance's storage).
+func (mc *MessageController) GetMessage(c *gin.Context) {
+	var message models.Message
+	err := database.DB.
+		Where("instance_id = ? AND message_id = ?", c.Param("instanceId"), c.Param("messageId")).
+		First(&message).Error
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "message not stored"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": message})
+}
+
+// DownloadMedia downloads and decrypts inbound media from its
+// encrypted-media bundle.
+func (mc *MessageController) DownloadMedia(c *gin.Context) {
+	var req models.DownloadMediaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	instance, ok := mc.getConnectedInstance(c, req.InstanceID)
+	if !ok {
+		return
+	}
+	msg := buildDownloadableMessage(&req)
+	if msg == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "type must be image, video, audio or document"})
+		return
+	}
+	data, err := instance.Client.DownloadAny(msg)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "download failed: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{
+		"media_base64": base64.StdEncoding.EncodeToString(data),
+		"mimetype":     req.Mimetype,
+		"size":         len(data),
+	}})
+}
+
+// buildDownloadableMessage reassembles a media message proto from a stored
+// encrypted-media bundle so whatsmeow can download it.
+func buildDownloadableMessage(req *models.DownloadMediaRequest) *waE2E.Message {
+	switch req.Type {
+	case "image":
+		return &waE2E.Message{ImageMessage: &waE2E.ImageMessage{
+			URL:           proto.String(req.MediaURL),
+			DirectPath:    proto.String(req.DirectPath),
+			MediaKey:      req.MediaKey,
+			FileEncSHA256: req.FileEncSHA256,
+			FileSHA256:    req.FileSHA256,
+			FileLength:    proto.Uint64(req.FileLength),
+			Mimetype:      proto.String(req.Mimetype),
+		}}
+	case "video":
+		return &waE2E.Message{VideoMessage: &waE2E.VideoMessage{
+			URL:           proto.String(req.MediaURL),
+			DirectPath:    proto.String(req.DirectPath),
+			MediaKey:      req.MediaKey,
+			FileEncSHA256: req.FileEncSHA256,
+			FileSHA256:    req.FileSHA256,
+			FileLength:    proto.Uint64(req.FileLength),
+			Mimetype:      proto.String(req.Mimetype),
+		}}
+	case "audio":
+		return &waE2E.Message{AudioMessage: &waE2E.AudioMessage{
+			URL:           proto.String(req.MediaURL),
+			DirectPath:    proto.String(req.DirectPath),
+			MediaKey:      req.MediaKey,
+			FileEncSHA256: req.FileEncSHA256,
+			FileSHA256:    req.FileSHA256,
+			FileLength:    proto.Uint64(req.FileLength),
+			Mimetype:      proto.String(req.Mimetype),
+		}}
+	case "document":
+		return &waE2E.Message{DocumentMessage: &waE2E.DocumentMessage{
+			URL:           proto.String(req.MediaURL),
+			DirectPath:    proto.String(req.DirectPath),
+			MediaKey:      req.MediaKey,
+			FileEncSHA256: req.FileEncSHA256,
+			FileSHA256:    req.FileSHA256,
+			FileLength:    proto.Uint64(req.FileLength),
+			Mimetype:      proto.String(req.Mimetype),
+		}}
+	default:
+		return nil
+	}
+}
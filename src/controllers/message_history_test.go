@@ -0,0 +1,97 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/Layoxd/whatsapiGo/src/models"
+)
+
+func historyTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("opening in-memory database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.Message{}); err != nil {
+		t.Fatalf("migrating: %v", err)
+	}
+	base := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		db.Create(&models.Message{
+			InstanceID: "inst-1",
+			MessageID:  "MSG" + string(rune('A'+i)),
+			ChatJID:    "5511999999999@s.whatsapp.net",
+			FromMe:     i%2 == 0,
+			Type:       "text",
+			Content:    "message",
+			Status:     models.MessageStatusSent,
+			Timestamp:  base.Add(time.Duration(i) * time.Hour),
+		})
+	}
+	db.Create(&models.Message{
+		InstanceID: "inst-2",
+		MessageID:  "OTHER",
+		ChatJID:    "5511888888888@s.whatsapp.net",
+		Timestamp:  base,
+	})
+	return db
+}
+
+func TestQueryMessageHistoryPagination(t *testing.T) {
+	db := historyTestDB(t)
+
+	history, err := queryMessageHistory(db, "inst-1", "", &models.MessageHistoryRequest{Limit: 2})
+	if err != nil {
+		t.Fatalf("queryMessageHistory: %v", err)
+	}
+	if history.Total != 5 {
+		t.Errorf("total = %d, want 5 (other instances must not leak in)", history.Total)
+	}
+	if len(history.Messages) != 2 {
+		t.Fatalf("page size = %d, want 2", len(history.Messages))
+	}
+	// Newest first: MSGE (base+4h) then MSGD.
+	if history.Messages[0].MessageID != "MSGE" || history.Messages[1].MessageID != "MSGD" {
+		t.Errorf("first page = [%s %s], want [MSGE MSGD]", history.Messages[0].MessageID, history.Messages[1].MessageID)
+	}
+
+	next, err := queryMessageHistory(db, "inst-1", "", &models.MessageHistoryRequest{Limit: 2, Offset: 4})
+	if err != nil {
+		t.Fatalf("queryMessageHistory offset: %v", err)
+	}
+	if len(next.Messages) != 1 || next.Messages[0].MessageID != "MSGA" {
+		t.Errorf("last page = %v, want just MSGA", next.Messages)
+	}
+}
+
+func TestQueryMessageHistoryTimeRange(t *testing.T) {
+	db := historyTestDB(t)
+
+	history, err := queryMessageHistory(db, "inst-1", "", &models.MessageHistoryRequest{
+		FromTime: "2025-06-01T13:00:00Z",
+		ToTime:   "2025-06-01T15:00:00Z",
+	})
+	if err != nil {
+		t.Fatalf("queryMessageHistory: %v", err)
+	}
+	if history.Total != 3 {
+		t.Errorf("total in range = %d, want 3", history.Total)
+	}
+	for _, msg := range history.Messages {
+		if msg.Timestamp.Before(time.Date(2025, 6, 1, 13, 0, 0, 0, time.UTC)) ||
+			msg.Timestamp.After(time.Date(2025, 6, 1, 15, 0, 0, 0, time.UTC)) {
+			t.Errorf("message %s at %v is outside the requested range", msg.MessageID, msg.Timestamp)
+		}
+	}
+
+	if _, err := queryMessageHistory(db, "inst-1", "", &models.MessageHistoryRequest{FromTime: "yesterday"}); err == nil {
+		t.Error("a non-RFC3339 from_time should be rejected")
+	}
+}
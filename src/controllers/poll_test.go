@@ -0,0 +1,43 @@
+package controllers
+
+import (
+	"testing"
+
+	"go.mau.fi/whatsmeow"
+)
+
+// TestBuildPollCreationMarshaling checks the shape of the poll message
+// SendPoll hands to whatsmeow: name, options in order and the selectable
+// count, plus the message secret votes are encrypted against.
+func TestBuildPollCreationMarshaling(t *testing.T) {
+	client := &whatsmeow.Client{}
+	msg := client.BuildPollCreation("Lunch?", []string{"Pizza", "Sushi"}, 1)
+
+	poll := msg.GetPollCreationMessage()
+	if poll == nil {
+		t.Fatal("BuildPollCreation returned no PollCreationMessage")
+	}
+	if poll.GetName() != "Lunch?" {
+		t.Errorf("poll name = %q, want Lunch?", poll.GetName())
+	}
+	options := poll.GetOptions()
+	if len(options) != 2 || options[0].GetOptionName() != "Pizza" || options[1].GetOptionName() != "Sushi" {
+		t.Errorf("poll options = %v, want [Pizza Sushi] in order", options)
+	}
+	if poll.GetSelectableOptionsCount() != 1 {
+		t.Errorf("selectable count = %d, want 1", poll.GetSelectableOptionsCount())
+	}
+	if len(msg.GetMessageContextInfo().GetMessageSecret()) != 32 {
+		t.Error("poll creation should carry a 32-byte message secret")
+	}
+}
+
+// TestBuildPollCreationClampsSelectableCount mirrors whatsmeow's
+// behavior of treating an out-of-range selectable count as 0 (any).
+func TestBuildPollCreationClampsSelectableCount(t *testing.T) {
+	client := &whatsmeow.Client{}
+	msg := client.BuildPollCreation("Q", []string{"A", "B"}, 5)
+	if got := msg.GetPollCreationMessage().GetSelectableOptionsCount(); got != 0 {
+		t.Errorf("selectable count = %d, want 0 for out-of-range input", got)
+	}
+}
@@ -0,0 +1,89 @@
+package controllers
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"go.mau.fi/whatsmeow/store/sqlstore"
+	"go.mau.fi/whatsmeow/types"
+	waLog "go.mau.fi/whatsmeow/util/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/Layoxd/whatsapiGo/src/database"
+	"github.com/Layoxd/whatsapiGo/src/models"
+)
+
+// TestRestoreInstances persists an instance row whose device lives in the
+// session store, wipes the live map and checks that RestoreInstances
+// brings the instance back bound to the same device JID.
+func TestRestoreInstances(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("opening in-memory database: %v", err)
+	}
+	if err := db.AutoMigrate(database.MigratedModels()...); err != nil {
+		t.Fatalf("migrating: %v", err)
+	}
+
+	waDB, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("opening in-memory session store: %v", err)
+	}
+	container := sqlstore.NewWithDB(waDB, "sqlite3", waLog.Noop)
+	if err := container.Upgrade(); err != nil {
+		t.Fatalf("migrating session store: %v", err)
+	}
+
+	oldDB, oldContainer := database.DB, database.Container
+	database.DB, database.Container = db, container
+	defer func() { database.DB, database.Container = oldDB, oldContainer }()
+
+	jid := types.NewADJID("5511999999999", 0, 1)
+	device := container.NewDevice()
+	device.ID = &jid
+	// The session store checks signature and key lengths; the values
+	// themselves are never verified here.
+	device.Account = &waProto.ADVSignedDeviceIdentity{
+		Details:             []byte{1},
+		AccountSignature:    make([]byte, 64),
+		AccountSignatureKey: make([]byte, 32),
+		DeviceSignature:     make([]byte, 64),
+	}
+	if err := container.PutDevice(device); err != nil {
+		t.Fatalf("saving device: %v", err)
+	}
+
+	db.Create(&models.Instance{
+		ID:        "11111111-aaaa-bbbb-cccc-222222222222",
+		Name:      "restored",
+		Status:    models.InstanceStatusDisconnected,
+		UserJID:   jid.ToNonAD().String(),
+		DeviceJID: jid.String(),
+		AutoRead:  true,
+	})
+
+	ic := NewInstanceController(nil)
+	restored, err := ic.RestoreInstances()
+	if err != nil {
+		t.Fatalf("RestoreInstances: %v", err)
+	}
+	if restored != 1 {
+		t.Fatalf("restored = %d, want 1", restored)
+	}
+
+	instance, ok := ic.getInstance("11111111-aaaa-bbbb-cccc-222222222222")
+	if !ok {
+		t.Fatal("restored instance missing from the live map")
+	}
+	if instance.Client.Store.ID == nil || instance.Client.Store.ID.String() != jid.String() {
+		t.Errorf("restored device JID = %v, want %s", instance.Client.Store.ID, jid)
+	}
+	if !instance.autoRead {
+		t.Error("auto_read setting was not restored")
+	}
+}
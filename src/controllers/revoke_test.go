@@ -0,0 +1,66 @@
+package controllers
+
+import (
+	"testing"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/store"
+	"go.mau.fi/whatsmeow/types"
+)
+
+func revokeTestInstance() *Instance {
+	own := types.NewJID("5511999999999", types.DefaultUserServer)
+	return &Instance{
+		ID:     "inst-1",
+		Client: &whatsmeow.Client{Store: &store.Device{ID: &own}},
+	}
+}
+
+func TestBuildRevokeKeyOwnMessage(t *testing.T) {
+	instance := revokeTestInstance()
+	group := types.NewJID("123456789-987654", types.GroupServer)
+
+	sender, err := revokeSender(instance, group, "")
+	if err != nil {
+		t.Fatalf("revokeSender: %v", err)
+	}
+	key := instance.Client.BuildRevoke(group, sender, "3EB0TARGET").GetProtocolMessage().GetKey()
+	if !key.GetFromMe() {
+		t.Error("revoking an own message should set from_me")
+	}
+	if key.GetID() != "3EB0TARGET" {
+		t.Errorf("key ID = %q, want 3EB0TARGET", key.GetID())
+	}
+	if key.GetRemoteJID() != group.String() {
+		t.Errorf("key remote JID = %q, want %s", key.GetRemoteJID(), group)
+	}
+	if key.GetParticipant() != "" {
+		t.Errorf("own revoke should not set participant, got %q", key.GetParticipant())
+	}
+}
+
+func TestBuildRevokeKeyAdminRevoke(t *testing.T) {
+	instance := revokeTestInstance()
+	group := types.NewJID("123456789-987654", types.GroupServer)
+
+	sender, err := revokeSender(instance, group, "5511888888888@s.whatsapp.net")
+	if err != nil {
+		t.Fatalf("revokeSender: %v", err)
+	}
+	key := instance.Client.BuildRevoke(group, sender, "3EB0TARGET").GetProtocolMessage().GetKey()
+	if key.GetFromMe() {
+		t.Error("revoking someone else's message should clear from_me")
+	}
+	if key.GetParticipant() != "5511888888888@s.whatsapp.net" {
+		t.Errorf("key participant = %q, want the original sender", key.GetParticipant())
+	}
+}
+
+func TestRevokeSenderRejectsDirectChatSender(t *testing.T) {
+	instance := revokeTestInstance()
+	direct := types.NewJID("5511888888888", types.DefaultUserServer)
+
+	if _, err := revokeSender(instance, direct, "5511888888888@s.whatsapp.net"); err == nil {
+		t.Error("sender_jid outside a group should be rejected")
+	}
+}
@@ -0,0 +1,121 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+
+	"github.com/Layoxd/whatsapiGo/src/config"
+)
+
+// slowSend pretends to be a send that takes longer than the deadline.
+func slowSend(ctx context.Context, duration time.Duration) error {
+	select {
+	case <-time.After(duration):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func TestSendContextOverride(t *testing.T) {
+	cfg := config.Load()
+	cfg.SendTimeoutSeconds = 30
+
+	ctx, cancel := sendContext(5)
+	defer cancel()
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		t.Fatal("expected a deadline with an override set")
+	}
+	if remaining := time.Until(deadline); remaining > 5*time.Second || remaining < 4*time.Second {
+		t.Errorf("override deadline = %v from now, want ~5s", remaining)
+	}
+
+	ctx, cancel = sendContext(0)
+	defer cancel()
+	deadline, ok = ctx.Deadline()
+	if !ok {
+		t.Fatal("expected a deadline from the configured default")
+	}
+	if remaining := time.Until(deadline); remaining > 30*time.Second || remaining < 29*time.Second {
+		t.Errorf("default deadline = %v from now, want ~30s", remaining)
+	}
+}
+
+func TestSendContextDisabled(t *testing.T) {
+	cfg := config.Load()
+	cfg.SendTimeoutSeconds = 0
+
+	ctx, cancel := sendContext(0)
+	defer cancel()
+	if _, ok := ctx.Deadline(); ok {
+		t.Error("expected no deadline when the timeout is disabled")
+	}
+}
+
+func TestSendErrorStatusTimeout(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	err := slowSend(ctx, time.Second)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("slow send returned %v, want deadline exceeded", err)
+	}
+	if status := sendErrorStatus(err); status != http.StatusGatewayTimeout {
+		t.Errorf("sendErrorStatus(timeout) = %d, want %d", status, http.StatusGatewayTimeout)
+	}
+	if status := sendErrorStatus(errors.New("server rejected")); status != http.StatusInternalServerError {
+		t.Errorf("sendErrorStatus(other) = %d, want %d", status, http.StatusInternalServerError)
+	}
+}
+
+func TestTransientSendError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"deadline exceeded", context.DeadlineExceeded, false},
+		{"canceled", context.Canceled, false},
+		{"not connected", whatsmeow.ErrNotConnected, true},
+		{"iq timed out", whatsmeow.ErrIQTimedOut, true},
+		{"message timed out", whatsmeow.ErrMessageTimedOut, true},
+		{"disconnected", whatsmeow.ErrIQDisconnected, true},
+		{"wrapped not connected", fmt.Errorf("sending: %w", whatsmeow.ErrNotConnected), true},
+		{"permanent", errors.New("message recipient must be a user JID"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := transientSendError(tt.err); got != tt.want {
+				t.Errorf("transientSendError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryEnabled(t *testing.T) {
+	cfg := config.Load()
+	cfg.SendRetryTransient = false
+	enabled := true
+	if retryEnabled(nil) {
+		t.Error("retryEnabled(nil) = true with config off")
+	}
+	if !retryEnabled(&enabled) {
+		t.Error("retryEnabled(&true) = false")
+	}
+	cfg.SendRetryTransient = true
+	disabled := false
+	if !retryEnabled(nil) {
+		t.Error("retryEnabled(nil) = false with config on")
+	}
+	if retryEnabled(&disabled) {
+		t.Error("retryEnabled(&false) = true")
+	}
+}
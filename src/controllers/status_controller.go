@@ -0,0 +1,428 @@
+package controllers
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/Layoxd/whatsapiGo/src/database"
+	"github.com/Layoxd/whatsapiGo/src/models"
+	"github.com/Layoxd/whatsapiGo/src/utils"
+)
+
+// StatusController handles status (story) publishing and the statuses
+// observed from contacts.
+type StatusController struct {
+	instanceController *InstanceController
+}
+
+// NewStatusController creates the status controller.
+func NewStatusController(ic *InstanceController) *StatusController {
+	return &StatusController{instanceController: ic}
+}
+
+// getConnectedInstance mirrors the message controller helper.
+func (sc *StatusController) getConnectedInstance(c *gin.Context, instanceID string) (*Instance, bool) {
+	instance, ok := sc.instanceController.getInstance(instanceID)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "instance not found"})
+		return nil, false
+	}
+	if !instance.Client.IsLoggedIn() {
+		c.JSON(http.StatusConflict, gin.H{"success": false, "error": "instance is not connected"})
+		return nil, false
+	}
+	return instance, true
+}
+
+// SendStatus publishes a text or media status update.
+func (sc *StatusController) SendStatus(c *gin.Context) {
+	var req models.SendStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	instance, ok := sc.getConnectedInstance(c, req.InstanceID)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := sendContext(req.TimeoutSeconds)
+	defer cancel()
+
+	var msg *waE2E.Message
+	switch req.Type {
+	case "text":
+		if req.Content == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "content is required for text statuses"})
+			return
+		}
+		msg = &waE2E.Message{ExtendedTextMessage: &waE2E.ExtendedTextMessage{
+			Text: proto.String(req.Content),
+		}}
+	case "image", "video":
+		if req.MediaBase64 == "" || req.Mimetype == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "media_base64 and mimetype are required for media statuses"})
+			return
+		}
+		data, err := base64.StdEncoding.DecodeString(req.MediaBase64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "invalid base64 media"})
+			return
+		}
+		mediaType := whatsmeow.MediaImage
+		if req.Type == "video" {
+			mediaType = whatsmeow.MediaVideo
+		}
+		uploaded, err := instance.Client.Upload(ctx, data, mediaType)
+		if err != nil {
+			c.JSON(sendErrorStatus(err), gin.H{"success": false, "error": "upload failed: " + err.Error()})
+			return
+		}
+		if req.Type == "image" {
+			msg = &waE2E.Message{ImageMessage: &waE2E.ImageMessage{
+				Caption:       proto.String(req.Content),
+				Mimetype:      proto.String(req.Mimetype),
+				URL:           &uploaded.URL,
+				DirectPath:    &uploaded.DirectPath,
+				MediaKey:      uploaded.MediaKey,
+				FileEncSHA256: uploaded.FileEncSHA256,
+				FileSHA256:    uploaded.FileSHA256,
+				FileLength:    proto.Uint64(uint64(len(data))),
+			}}
+		} else {
+			msg = &waE2E.Message{VideoMessage: &waE2E.VideoMessage{
+				Caption:       proto.String(req.Content),
+				Mimetype:      proto.String(req.Mimetype),
+				URL:           &uploaded.URL,
+				DirectPath:    &uploaded.DirectPath,
+				MediaKey:      uploaded.MediaKey,
+				FileEncSHA256: uploaded.FileEncSHA256,
+				FileSHA256:    uploaded.FileSHA256,
+				FileLength:    proto.Uint64(uint64(len(data))),
+			}}
+		}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "type must be text, image or video"})
+		return
+	}
+
+	resp, err := instance.Client.SendMessage(ctx, types.StatusBroadcastJID, msg)
+	if err != nil {
+		c.JSON(sendErrorStatus(err), gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{
+		"status_id": resp.ID,
+		"timestamp": resp.Timestamp,
+	}})
+}
+
+// GetContactStatuses lists the statuses observed from contacts that have
+// not yet expired.
+func (sc *StatusController) GetContactStatuses(c *gin.Context) {
+	instanceID := c.Param("instanceId")
+	if _, ok := sc.instanceController.getInstance(instanceID); !ok {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "instance not found"})
+		return
+	}
+	var statuses []models.Status
+	if err := database.DB.
+		Where("instance_id = ? AND expires_at > CURRENT_TIMESTAMP", instanceID).
+		Order("timestamp DESC").
+		Find(&statuses).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": statuses})
+}
+
+// GetStatusMedia downloads and returns the media of a stored contact
+// status using its encrypted-media bundle. Expired statuses return 410.
+func (sc *StatusController) GetStatusMedia(c *gin.Context) {
+	instance, ok := sc.getConnectedInstance(c, c.Param("instanceId"))
+	if !ok {
+		return
+	}
+	var status models.Status
+	err := database.DB.
+		Where("instance_id = ? AND status_id = ?", instance.ID, c.Param("statusId")).
+		First(&status).Error
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "status not found"})
+		return
+	}
+	if time.Now().After(status.ExpiresAt) {
+		c.JSON(http.StatusGone, gin.H{"success": false, "error": "status has expired"})
+		return
+	}
+	if len(status.MediaKey) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "status has no downloadable media"})
+		return
+	}
+
+	msg := buildDownloadableMessage(&models.DownloadMediaRequest{
+		MediaURL:      status.MediaURL,
+		DirectPath:    status.DirectPath,
+		MediaKey:      status.MediaKey,
+		FileEncSHA256: status.FileEncSHA256,
+		FileSHA256:    status.FileSHA256,
+		FileLength:    status.FileLength,
+		Mimetype:      status.Mimetype,
+		Type:          status.Type,
+	})
+	if msg == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "unsupported status media type"})
+		return
+	}
+	data, err := instance.Client.DownloadAny(msg)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "download failed: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{
+		"status_id":    status.StatusID,
+		"author_jid":   status.AuthorJID,
+		"media_base64": base64.StdEncoding.EncodeToString(data),
+		"mimetype":     status.Mimetype,
+		"size":         len(data),
+	}})
+}
+
+// ForwardStatus re-sends a stored contact status to normal chats. Media
+// statuses are downloaded with the stored encrypted-media bundle and
+// re-uploaded so the recipients get a fresh attachment; text statuses
+// are forwarded as plain text messages.
+func (sc *StatusController) ForwardStatus(c *gin.Context) {
+	var req struct {
+		// Recipients are phone numbers or full JIDs (groups included).
+		Recipients     []string `json:"recipients" binding:"required,min=1"`
+		TimeoutSeconds int      `json:"timeout_seconds,omitempty"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	instance, ok := sc.getConnectedInstance(c, c.Param("instanceId"))
+	if !ok {
+		return
+	}
+
+	recipients := make([]types.JID, 0, len(req.Recipients))
+	for _, recipient := range req.Recipients {
+		if strings.Contains(recipient, "@") {
+			jid, err := types.ParseJID(recipient)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "invalid recipient: " + recipient})
+				return
+			}
+			recipients = append(recipients, jid)
+		} else {
+			recipients = append(recipients, utils.UserJID(recipient))
+		}
+	}
+
+	var status models.Status
+	err := database.DB.
+		Where("instance_id = ? AND status_id = ?", instance.ID, c.Param("statusId")).
+		First(&status).Error
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "status not found"})
+		return
+	}
+	if time.Now().After(status.ExpiresAt) {
+		c.JSON(http.StatusGone, gin.H{"success": false, "error": "status has expired"})
+		return
+	}
+
+	ctx, cancel := sendContext(req.TimeoutSeconds)
+	defer cancel()
+
+	forwarded := &waE2E.ContextInfo{
+		IsForwarded:     proto.Bool(true),
+		ForwardingScore: proto.Uint32(1),
+	}
+	var msg *waE2E.Message
+	switch status.Type {
+	case "text":
+		msg = &waE2E.Message{ExtendedTextMessage: &waE2E.ExtendedTextMessage{
+			Text:        proto.String(status.Content),
+			ContextInfo: forwarded,
+		}}
+	case "image", "video":
+		source := buildDownloadableMessage(&models.DownloadMediaRequest{
+			MediaURL:      status.MediaURL,
+			DirectPath:    status.DirectPath,
+			MediaKey:      status.MediaKey,
+			FileEncSHA256: status.FileEncSHA256,
+			FileSHA256:    status.FileSHA256,
+			FileLength:    status.FileLength,
+			Mimetype:      status.Mimetype,
+			Type:          status.Type,
+		})
+		data, err := instance.Client.DownloadAny(source)
+		if err != nil {
+			// The status servers drop media after expiry; report that as
+			// gone rather than as a server error.
+			if errors.Is(err, whatsmeow.ErrMediaDownloadFailedWith404) || errors.Is(err, whatsmeow.ErrMediaDownloadFailedWith410) {
+				c.JSON(http.StatusGone, gin.H{"success": false, "error": "status media is no longer available"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "download failed: " + err.Error()})
+			return
+		}
+		mediaType := whatsmeow.MediaImage
+		if status.Type == "video" {
+			mediaType = whatsmeow.MediaVideo
+		}
+		uploaded, err := instance.Client.Upload(ctx, data, mediaType)
+		if err != nil {
+			c.JSON(sendErrorStatus(err), gin.H{"success": false, "error": "upload failed: " + err.Error()})
+			return
+		}
+		if status.Type == "image" {
+			msg = &waE2E.Message{ImageMessage: &waE2E.ImageMessage{
+				Caption:       proto.String(status.Content),
+				Mimetype:      proto.String(status.Mimetype),
+				URL:           &uploaded.URL,
+				DirectPath:    &uploaded.DirectPath,
+				MediaKey:      uploaded.MediaKey,
+				FileEncSHA256: uploaded.FileEncSHA256,
+				FileSHA256:    uploaded.FileSHA256,
+				FileLength:    proto.Uint64(uint64(len(data))),
+				ContextInfo:   forwarded,
+			}}
+		} else {
+			msg = &waE2E.Message{VideoMessage: &waE2E.VideoMessage{
+				Caption:       proto.String(status.Content),
+				Mimetype:      proto.String(status.Mimetype),
+				URL:           &uploaded.URL,
+				DirectPath:    &uploaded.DirectPath,
+				MediaKey:      uploaded.MediaKey,
+				FileEncSHA256: uploaded.FileEncSHA256,
+				FileSHA256:    uploaded.FileSHA256,
+				FileLength:    proto.Uint64(uint64(len(data))),
+				ContextInfo:   forwarded,
+			}}
+		}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "status type cannot be forwarded: " + status.Type})
+		return
+	}
+
+	results := make([]gin.H, 0, len(recipients))
+	sent := 0
+	for _, jid := range recipients {
+		resp, err := instance.Client.SendMessage(ctx, jid, msg)
+		if err != nil {
+			results = append(results, gin.H{"recipient": jid.String(), "error": err.Error()})
+			continue
+		}
+		sent++
+		results = append(results, gin.H{"recipient": jid.String(), "message_id": resp.ID})
+	}
+	c.JSON(http.StatusOK, gin.H{"success": sent > 0, "data": gin.H{
+		"status_id": status.StatusID,
+		"sent":      sent,
+		"failed":    len(recipients) - sent,
+		"results":   results,
+	}})
+}
+
+// ReactToStatus sends an emoji reaction to a contact's status. The
+// reaction is keyed against the status broadcast chat and delivered to
+// the author.
+func (sc *StatusController) ReactToStatus(c *gin.Context) {
+	var req struct {
+		Emoji     string `json:"emoji" binding:"required"`
+		AuthorJID string `json:"author_jid,omitempty"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	if !isSingleEmoji(req.Emoji) {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "emoji must be a single emoji"})
+		return
+	}
+	instance, ok := sc.getConnectedInstance(c, c.Param("instanceId"))
+	if !ok {
+		return
+	}
+
+	statusID := c.Param("statusId")
+	authorJID := req.AuthorJID
+	var status models.Status
+	err := database.DB.
+		Where("instance_id = ? AND status_id = ?", instance.ID, statusID).
+		First(&status).Error
+	if err == nil {
+		if time.Now().After(status.ExpiresAt) {
+			c.JSON(http.StatusGone, gin.H{"success": false, "error": "status has expired"})
+			return
+		}
+		authorJID = status.AuthorJID
+	}
+	if authorJID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "author_jid is required for statuses not seen by this instance"})
+		return
+	}
+	author, err := types.ParseJID(authorJID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "invalid author_jid"})
+		return
+	}
+
+	reaction := instance.Client.BuildReaction(types.StatusBroadcastJID, author, statusID, req.Emoji)
+	ctx, cancel := sendContext(0)
+	defer cancel()
+	if _, err := instance.Client.SendMessage(ctx, author.ToNonAD(), reaction); err != nil {
+		c.JSON(sendErrorStatus(err), gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "reaction sent"})
+}
+
+// isSingleEmoji loosely checks that the string is one emoji: non-empty,
+// no whitespace and short enough to be a single grapheme with modifiers.
+func isSingleEmoji(s string) bool {
+	if s == "" || strings.ContainsAny(s, " \t\n") {
+		return false
+	}
+	return utf8.RuneCountInString(s) <= 8
+}
+
+// DeleteStatus revokes one of the instance's own statuses.
+func (sc *StatusController) DeleteStatus(c *gin.Context) {
+	instance, ok := sc.getConnectedInstance(c, c.Param("instanceId"))
+	if !ok {
+		return
+	}
+	if err := sc.deleteOwnStatus(instance, c.Param("statusId")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "status deleted"})
+}
+
+// deleteOwnStatus sends the revoke protocol message for an own status.
+func (sc *StatusController) deleteOwnStatus(instance *Instance, statusID string) error {
+	sender := types.EmptyJID
+	if instance.Client.Store.ID != nil {
+		sender = instance.Client.Store.ID.ToNonAD()
+	}
+	revoke := instance.Client.BuildRevoke(types.StatusBroadcastJID, sender, statusID)
+	ctx, cancel := sendContext(0)
+	defer cancel()
+	_, err := instance.Client.SendMessage(ctx, types.StatusBroadcastJID, revoke)
+	return err
+}
@@ -0,0 +1,138 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/Layoxd/whatsapiGo/src/database"
+	"github.com/Layoxd/whatsapiGo/src/models"
+	"github.com/Layoxd/whatsapiGo/src/utils"
+)
+
+// TemplateController manages reusable message templates.
+type TemplateController struct {
+	instanceController *InstanceController
+}
+
+// NewTemplateController creates the template controller.
+func NewTemplateController(ic *InstanceController) *TemplateController {
+	return &TemplateController{instanceController: ic}
+}
+
+// templateDetail renders a template with its derived parameter list.
+func templateDetail(t *models.MessageTemplate) gin.H {
+	return gin.H{
+		"id":          t.ID,
+		"instance_id": t.InstanceID,
+		"name":        t.Name,
+		"content":     t.Content,
+		"params":      utils.TemplateParams(t.Content),
+		"created_at":  t.CreatedAt,
+		"updated_at":  t.UpdatedAt,
+	}
+}
+
+// GetTemplates lists the templates of an instance.
+func (tc *TemplateController) GetTemplates(c *gin.Context) {
+	instanceID := c.Param("instanceId")
+	if _, ok := tc.instanceController.getInstance(instanceID); !ok {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "instance not found"})
+		return
+	}
+	var templates []models.MessageTemplate
+	if err := database.DB.
+		Where("instance_id = ?", instanceID).
+		Order("name ASC").
+		Find(&templates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	out := make([]gin.H, 0, len(templates))
+	for i := range templates {
+		out = append(out, templateDetail(&templates[i]))
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": out})
+}
+
+// CreateTemplate stores a new template.
+func (tc *TemplateController) CreateTemplate(c *gin.Context) {
+	var req models.CreateTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	instanceID := c.Param("instanceId")
+	if _, ok := tc.instanceController.getInstance(instanceID); !ok {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "instance not found"})
+		return
+	}
+	template := models.MessageTemplate{
+		ID:         uuid.NewString(),
+		InstanceID: instanceID,
+		Name:       req.Name,
+		Content:    req.Content,
+	}
+	if err := database.DB.Create(&template).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"success": true, "data": templateDetail(&template)})
+}
+
+// UpdateTemplate changes a template's name and/or content.
+func (tc *TemplateController) UpdateTemplate(c *gin.Context) {
+	var req models.UpdateTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	template, ok := tc.getTemplate(c)
+	if !ok {
+		return
+	}
+	if req.Name != nil {
+		template.Name = *req.Name
+	}
+	if req.Content != nil {
+		template.Content = *req.Content
+	}
+	if err := database.DB.Save(template).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": templateDetail(template)})
+}
+
+// DeleteTemplate removes a template.
+func (tc *TemplateController) DeleteTemplate(c *gin.Context) {
+	template, ok := tc.getTemplate(c)
+	if !ok {
+		return
+	}
+	if err := database.DB.Delete(template).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "template deleted"})
+}
+
+// getTemplate loads the template addressed by the path, writing the
+// error response when the instance or template does not exist.
+func (tc *TemplateController) getTemplate(c *gin.Context) (*models.MessageTemplate, bool) {
+	instanceID := c.Param("instanceId")
+	if _, ok := tc.instanceController.getInstance(instanceID); !ok {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "instance not found"})
+		return nil, false
+	}
+	var template models.MessageTemplate
+	err := database.DB.
+		Where("instance_id = ? AND id = ?", instanceID, c.Param("templateId")).
+		First(&template).Error
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "template not found"})
+		return nil, false
+	}
+	return &template, true
+}
@@ -0,0 +1,923 @@
+package controllers
+
+import (
+	"crypto/hmac"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/Layoxd/whatsapiGo/src/config"
+	"github.com/Layoxd/whatsapiGo/src/database"
+	"github.com/Layoxd/whatsapiGo/src/models"
+	"github.com/Layoxd/whatsapiGo/src/services"
+)
+
+// WebhookController manages webhook configuration and delivery logs.
+type WebhookController struct {
+	webhookService *services.WebhookService
+
+	// replayMu guards lastReplay, which rate-limits the replay endpoint
+	// per instance.
+	replayMu   sync.Mutex
+	lastReplay map[string]time.Time
+}
+
+// NewWebhookController creates the webhook controller.
+func NewWebhookController(ws *services.WebhookService) *WebhookController {
+	return &WebhookController{
+		webhookService: ws,
+		lastReplay:     make(map[string]time.Time),
+	}
+}
+
+// ConfigureWebhook creates a webhook for an instance. The instance ID "*"
+// creates a global webhook that receives matching events from every
+// instance.
+func (wc *WebhookController) ConfigureWebhook(c *gin.Context) {
+	var req models.ConfigureWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	if _, err := url.ParseRequestURI(req.URL); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "invalid webhook URL"})
+		return
+	}
+	subscribed := req.Events
+	if len(subscribed) == 0 {
+		subscribed = config.Get().DefaultWebhookEvents
+	}
+	if len(subscribed) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "events is required and no default subscription is configured"})
+		return
+	}
+	if !validEventList(c, subscribed) {
+		return
+	}
+	events, err := json.Marshal(subscribed)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	webhook := models.Webhook{
+		ID:         uuid.NewString(),
+		InstanceID: c.Param("instanceId"),
+		URL:        req.URL,
+		Secret:     req.Secret,
+		Events:     string(events),
+		Active:     true,
+	}
+	if req.Active != nil {
+		webhook.Active = *req.Active
+	}
+	if !applyTLSOptions(c, &webhook, &req) {
+		return
+	}
+	if !applyTransform(c, &webhook, &req) {
+		return
+	}
+	if !applySignatureAlgorithm(c, &webhook, &req) {
+		return
+	}
+	if req.PresenceThrottleSeconds != nil {
+		webhook.PresenceThrottleSeconds = *req.PresenceThrottleSeconds
+	}
+	if err := database.DB.Create(&webhook).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"success": true, "data": webhook})
+}
+
+// validEventList checks every entry against the event catalog ("*" is the
+// wildcard subscription) and writes the error response on failure.
+func validEventList(c *gin.Context, events []string) bool {
+	for _, ev := range events {
+		if ev != "*" && !services.KnownEventType(ev) {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "unknown event type: " + ev})
+			return false
+		}
+	}
+	return true
+}
+
+// applyTLSOptions copies the TLS delivery options from a configure
+// request onto a webhook, validating the CA bundle up front so a typoed
+// path fails the request instead of every future delivery. It writes the
+// error response itself and reports whether to continue.
+func applyTLSOptions(c *gin.Context, webhook *models.Webhook, req *models.ConfigureWebhookRequest) bool {
+	if req.InsecureTLS != nil {
+		webhook.InsecureTLS = *req.InsecureTLS
+		if webhook.InsecureTLS {
+			log.Printf("webhook %s: TLS certificate verification disabled by configuration", webhook.ID)
+		}
+	}
+	if req.CACertPath != nil {
+		if *req.CACertPath != "" {
+			pem, err := os.ReadFile(*req.CACertPath)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "reading ca_cert_path: " + err.Error()})
+				return false
+			}
+			if !x509.NewCertPool().AppendCertsFromPEM(pem) {
+				c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "ca_cert_path contains no PEM certificates"})
+				return false
+			}
+		}
+		webhook.CACertPath = *req.CACertPath
+	}
+	return true
+}
+
+// applySignatureAlgorithm validates and stores the delivery signing
+// algorithm, writing the error response itself and reporting whether to
+// continue.
+func applySignatureAlgorithm(c *gin.Context, webhook *models.Webhook, req *models.ConfigureWebhookRequest) bool {
+	if req.SignatureAlgorithm == nil {
+		return true
+	}
+	if !services.ValidSignatureAlgorithm(*req.SignatureAlgorithm) {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "signature_algorithm must be sha256 or sha512"})
+		return false
+	}
+	webhook.SignatureAlgorithm = *req.SignatureAlgorithm
+	return true
+}
+
+// applyTransform validates and stores the optional payload transform
+// template, so a broken template fails the request instead of silently
+// degrading every delivery. It writes the error response itself and
+// reports whether to continue.
+func applyTransform(c *gin.Context, webhook *models.Webhook, req *models.ConfigureWebhookRequest) bool {
+	if req.Transform == nil {
+		return true
+	}
+	if *req.Transform != "" {
+		if _, err := template.New("transform").Parse(*req.Transform); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "invalid transform template: " + err.Error()})
+			return false
+		}
+	}
+	webhook.Transform = *req.Transform
+	return true
+}
+
+// GetWebhooks lists the webhooks of an instance with their active mutes.
+func (wc *WebhookController) GetWebhooks(c *gin.Context) {
+	var webhooks []models.Webhook
+	if err := database.DB.Where("instance_id = ?", c.Param("instanceId")).Find(&webhooks).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	out := make([]gin.H, 0, len(webhooks))
+	for i := range webhooks {
+		detail := gin.H{"webhook": webhooks[i]}
+		if mutes := wc.webhookService.ActiveMutes(webhooks[i].ID); len(mutes) > 0 {
+			detail["active_mutes"] = mutes
+		}
+		out = append(out, detail)
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": out})
+}
+
+// maxMuteDuration caps temporary mutes; anything longer should be an
+// explicit unsubscribe instead.
+const maxMuteDuration = 24 * time.Hour
+
+// MuteWebhookEvents temporarily silences the given event types for a
+// webhook. An existing mute for the same event type has its expiry
+// replaced.
+func (wc *WebhookController) MuteWebhookEvents(c *gin.Context) {
+	var req struct {
+		Events          []string `json:"events" binding:"required,min=1"`
+		DurationSeconds int      `json:"duration_seconds" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	duration := time.Duration(req.DurationSeconds) * time.Second
+	if duration <= 0 || duration > maxMuteDuration {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "duration_seconds must be between 1 and 86400"})
+		return
+	}
+	for _, eventType := range req.Events {
+		if !services.KnownEventType(eventType) {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "unknown event type: " + eventType})
+			return
+		}
+	}
+	webhook, ok := wc.getWebhook(c)
+	if !ok {
+		return
+	}
+
+	expiresAt := time.Now().Add(duration)
+	for _, eventType := range req.Events {
+		var mute models.WebhookMute
+		err := database.DB.
+			Where("webhook_id = ? AND event_type = ?", webhook.ID, eventType).
+			First(&mute).Error
+		if err != nil {
+			database.DB.Create(&models.WebhookMute{
+				WebhookID: webhook.ID,
+				EventType: eventType,
+				ExpiresAt: expiresAt,
+			})
+			continue
+		}
+		database.DB.Model(&mute).Update("expires_at", expiresAt)
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{
+		"webhook_id": webhook.ID,
+		"muted":      req.Events,
+		"expires_at": expiresAt,
+	}})
+}
+
+// UnmuteWebhookEvents lifts mutes before they expire; with no events in
+// the body every mute on the webhook is removed.
+func (wc *WebhookController) UnmuteWebhookEvents(c *gin.Context) {
+	var req struct {
+		Events []string `json:"events"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	webhook, ok := wc.getWebhook(c)
+	if !ok {
+		return
+	}
+	query := database.DB.Where("webhook_id = ?", webhook.ID)
+	if len(req.Events) > 0 {
+		query = query.Where("event_type IN ?", req.Events)
+	}
+	result := query.Delete(&models.WebhookMute{})
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{
+		"webhook_id": webhook.ID,
+		"removed":    result.RowsAffected,
+	}})
+}
+
+// getWebhook loads one webhook scoped to the instance in the path.
+func (wc *WebhookController) getWebhook(c *gin.Context) (*models.Webhook, bool) {
+	var webhook models.Webhook
+	err := database.DB.
+		Where("id = ? AND instance_id = ?", c.Param("webhookId"), c.Param("instanceId")).
+		First(&webhook).Error
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "webhook not found"})
+		return nil, false
+	}
+	return &webhook, true
+}
+
+// VerifyWebhookSignature checks a received webhook signature against the
+// stored secret, so consumers can validate deliveries during development
+// without having the secret themselves.
+func (wc *WebhookController) VerifyWebhookSignature(c *gin.Context) {
+	var req models.VerifyWebhookSignatureRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	var webhook models.Webhook
+	err := database.DB.
+		Where("id = ? AND instance_id = ?", req.WebhookID, c.Param("instanceId")).
+		First(&webhook).Error
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "webhook not found"})
+		return
+	}
+	if webhook.Secret == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "webhook has no secret configured"})
+		return
+	}
+
+	algorithm := services.SignatureAlgorithm(&webhook)
+	provided := strings.TrimPrefix(req.Signature, algorithm+"=")
+	expected := services.SignPayload(algorithm, webhook.Secret, req.Timestamp, []byte(req.Body))
+	valid := hmac.Equal([]byte(provided), []byte(expected))
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{
+		"webhook_id": webhook.ID,
+		"valid":      valid,
+	}})
+}
+
+// GetEffectiveSubscription resolves what a webhook will actually receive:
+// the subscribed events expanded against the catalog (wildcards resolved,
+// unknown names reported) together with the active filters, so integrators
+// can debug missing deliveries in one place.
+func (wc *WebhookController) GetEffectiveSubscription(c *gin.Context) {
+	webhook, ok := wc.getWebhook(c)
+	if !ok {
+		return
+	}
+	var subscribed []string
+	if err := json.Unmarshal([]byte(webhook.Events), &subscribed); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "stored event list is corrupt: " + err.Error()})
+		return
+	}
+
+	resolved := make([]string, 0, len(services.EventCatalog))
+	unknown := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, ev := range subscribed {
+		if ev == "*" {
+			for _, known := range services.EventCatalog {
+				if !seen[known] {
+					seen[known] = true
+					resolved = append(resolved, known)
+				}
+			}
+			continue
+		}
+		if !services.KnownEventType(ev) {
+			unknown = append(unknown, ev)
+			continue
+		}
+		if !seen[ev] {
+			seen[ev] = true
+			resolved = append(resolved, ev)
+		}
+	}
+
+	var filters []models.WebhookFilter
+	database.DB.Where("webhook_id = ?", webhook.ID).Find(&filters)
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{
+		"webhook_id":     webhook.ID,
+		"active":         webhook.Active,
+		"subscribed":     subscribed,
+		"events":         resolved,
+		"unknown_events": unknown,
+		"filters":        filters,
+	}})
+}
+
+// UpdateWebhook updates a webhook's URL, secret, events or active flag.
+func (wc *WebhookController) UpdateWebhook(c *gin.Context) {
+	webhook, ok := wc.getWebhook(c)
+	if !ok {
+		return
+	}
+	var req models.ConfigureWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	if _, err := url.ParseRequestURI(req.URL); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "invalid webhook URL"})
+		return
+	}
+	webhook.URL = req.URL
+	if req.Secret != "" {
+		webhook.Secret = req.Secret
+	}
+	if len(req.Events) > 0 {
+		if !validEventList(c, req.Events) {
+			return
+		}
+		events, _ := json.Marshal(req.Events)
+		webhook.Events = string(events)
+	}
+	if req.Active != nil {
+		webhook.Active = *req.Active
+	}
+	if !applyTLSOptions(c, webhook, &req) {
+		return
+	}
+	if !applyTransform(c, webhook, &req) {
+		return
+	}
+	if !applySignatureAlgorithm(c, webhook, &req) {
+		return
+	}
+	if req.PresenceThrottleSeconds != nil {
+		webhook.PresenceThrottleSeconds = *req.PresenceThrottleSeconds
+	}
+	if err := database.DB.Save(webhook).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": webhook})
+}
+
+// DeleteWebhook removes a webhook and its filters.
+func (wc *WebhookController) DeleteWebhook(c *gin.Context) {
+	webhook, ok := wc.getWebhook(c)
+	if !ok {
+		return
+	}
+	database.DB.Where("webhook_id = ?", webhook.ID).Delete(&models.WebhookFilter{})
+	database.DB.Delete(webhook)
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "webhook deleted"})
+}
+
+// TestWebhook posts a static test payload to the webhook endpoint.
+func (wc *WebhookController) TestWebhook(c *gin.Context) {
+	webhook, ok := wc.getWebhook(c)
+	if !ok {
+		return
+	}
+	body, _ := json.Marshal(gin.H{
+		"event": "webhook.test",
+		"data": gin.H{
+			"instance_id": webhook.InstanceID,
+			"webhook_id":  webhook.ID,
+			"timestamp":   time.Now(),
+		},
+	})
+	status, err := wc.postPayload(webhook, "webhook.test", body)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{"status_code": status}})
+}
+
+// SimulateEvent dispatches a caller-built event of a known type through
+// the real classification-free delivery path, so integrators can exercise
+// their handlers for specific event shapes without waiting for traffic.
+func (wc *WebhookController) SimulateEvent(c *gin.Context) {
+	var req struct {
+		EventType string                 `json:"event_type" binding:"required"`
+		Data      map[string]interface{} `json:"data"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	if !services.KnownEventType(req.EventType) {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "unknown event type, see /capabilities"})
+		return
+	}
+	payload := wc.webhookService.SimulateEvent(c.Param("instanceId"), req.EventType, req.Data)
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{
+		"event_type": req.EventType,
+		"payload":    payload,
+	}})
+}
+
+// retryFailedDelay spaces out bulk redeliveries so a recovering endpoint
+// is not immediately flooded.
+const retryFailedDelay = 250 * time.Millisecond
+
+// RetryFailedEvents re-sends every failed delivery of a webhook through
+// the normal delivery path and reports how many succeeded.
+func (wc *WebhookController) RetryFailedEvents(c *gin.Context) {
+	webhook, ok := wc.getWebhook(c)
+	if !ok {
+		return
+	}
+	var failed []models.WebhookLog
+	if err := database.DB.
+		Where("webhook_id = ? AND success = ?", webhook.ID, false).
+		Order("created_at ASC").
+		Find(&failed).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	succeeded := 0
+	for i := range failed {
+		if i > 0 {
+			time.Sleep(retryFailedDelay)
+		}
+		if wc.webhookService.Redeliver(webhook, &failed[i]) {
+			succeeded++
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{
+		"retried":   len(failed),
+		"succeeded": succeeded,
+		"failed":    len(failed) - succeeded,
+	}})
+}
+
+// PauseAllDeliveries pauses webhook deliveries for a whole instance.
+// Events keep being logged while paused so they can be replayed on resume.
+func (wc *WebhookController) PauseAllDeliveries(c *gin.Context) {
+	instanceID := c.Param("instanceId")
+	wc.webhookService.PauseDeliveries(instanceID)
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{
+		"instance_id": instanceID,
+		"paused":      true,
+	}})
+}
+
+// ResumeAllDeliveries resumes webhook deliveries for an instance. With
+// ?replay=true the events logged while paused are dispatched first.
+func (wc *WebhookController) ResumeAllDeliveries(c *gin.Context) {
+	instanceID := c.Param("instanceId")
+	replay := c.Query("replay") == "true"
+	replayed := wc.webhookService.ResumeDeliveries(instanceID, replay)
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{
+		"instance_id": instanceID,
+		"paused":      false,
+		"replayed":    replayed,
+	}})
+}
+
+// Replay limits: a cooldown between runs per instance so the endpoint
+// cannot be hammered, and a cap on the requested range to keep a single
+// run bounded.
+const (
+	replayCooldown = time.Minute
+	maxReplayRange = 24 * time.Hour
+)
+
+// ReplayStoredEvents re-emits the logged events of an instance in a time
+// range to its active webhooks, for recovery after a downstream outage.
+// Unlike retry, it also covers events that never produced a delivery
+// attempt (paused instances aside, those are only in the event log).
+func (wc *WebhookController) ReplayStoredEvents(c *gin.Context) {
+	var req struct {
+		From   time.Time `json:"from" binding:"required"`
+		To     time.Time `json:"to" binding:"required"`
+		Events []string  `json:"events"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	if !req.To.After(req.From) {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "to must be after from"})
+		return
+	}
+	if req.To.Sub(req.From) > maxReplayRange {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "time range must not exceed 24 hours"})
+		return
+	}
+	for _, eventType := range req.Events {
+		if !services.KnownEventType(eventType) {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "unknown event type: " + eventType})
+			return
+		}
+	}
+	instanceID := c.Param("instanceId")
+
+	wc.replayMu.Lock()
+	if wait := replayCooldown - time.Since(wc.lastReplay[instanceID]); wait > 0 {
+		wc.replayMu.Unlock()
+		c.Header("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+		c.JSON(http.StatusTooManyRequests, gin.H{"success": false, "error": "replay was run recently for this instance, try again later"})
+		return
+	}
+	wc.lastReplay[instanceID] = time.Now()
+	wc.replayMu.Unlock()
+
+	replayed, err := wc.webhookService.ReplayEvents(instanceID, req.From, req.To, req.Events)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{
+		"instance_id": instanceID,
+		"from":        req.From,
+		"to":          req.To,
+		"replayed":    replayed,
+	}})
+}
+
+// GetWebhookLogs lists the delivery logs of a webhook.
+func (wc *WebhookController) GetWebhookLogs(c *gin.Context) {
+	webhook, ok := wc.getWebhook(c)
+	if !ok {
+		return
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if limit <= 0 || limit > 500 {
+		limit = 50
+	}
+	var logs []models.WebhookLog
+	if err := database.DB.
+		Where("webhook_id = ?", webhook.ID).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&logs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": logs})
+}
+
+// RetryEvent re-delivers the payload of one logged delivery.
+func (wc *WebhookController) RetryEvent(c *gin.Context) {
+	var logEntry models.WebhookLog
+	err := database.DB.
+		Where("id = ? AND instance_id = ?", c.Param("logId"), c.Param("instanceId")).
+		First(&logEntry).Error
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "log entry not found"})
+		return
+	}
+	var webhook models.Webhook
+	if err := database.DB.Where("id = ?", logEntry.WebhookID).First(&webhook).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "webhook no longer exists"})
+		return
+	}
+	status, err := wc.postPayload(&webhook, logEntry.EventType, []byte(logEntry.Payload))
+	success := err == nil && status >= 200 && status < 300
+	database.DB.Model(&logEntry).Updates(map[string]interface{}{
+		"attempts":    logEntry.Attempts + 1,
+		"status_code": status,
+		"success":     success,
+	})
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{
+		"delivered":   success,
+		"status_code": status,
+	}})
+}
+
+// postPayload performs a single signed delivery to a webhook endpoint
+// through the service's delivery path, so the signature algorithm and
+// headers cannot drift from real event deliveries.
+func (wc *WebhookController) postPayload(webhook *models.Webhook, eventType string, body []byte) (int, error) {
+	return wc.webhookService.DeliverPayload(webhook, eventType, body)
+}
+
+// AddWebhookFilter adds a dispatch filter to a webhook.
+func (wc *WebhookController) AddWebhookFilter(c *gin.Context) {
+	webhook, ok := wc.getWebhook(c)
+	if !ok {
+		return
+	}
+	var req struct {
+		Field    string `json:"field" binding:"required"`
+		Operator string `json:"operator"`
+		Value    string `json:"value" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	switch req.Operator {
+	case "", "equals", "not_equals", "contains":
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "operator must be equals, not_equals or contains"})
+		return
+	}
+	filter := models.WebhookFilter{
+		WebhookID: webhook.ID,
+		Field:     req.Field,
+		Operator:  req.Operator,
+		Value:     req.Value,
+	}
+	if err := database.DB.Create(&filter).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"success": true, "data": filter})
+}
+
+// GetWebhookFilters lists the filters of a webhook.
+func (wc *WebhookController) GetWebhookFilters(c *gin.Context) {
+	webhook, ok := wc.getWebhook(c)
+	if !ok {
+		return
+	}
+	var filters []models.WebhookFilter
+	database.DB.Where("webhook_id = ?", webhook.ID).Find(&filters)
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": filters})
+}
+
+// DeleteWebhookFilter removes one filter from a webhook.
+func (wc *WebhookController) DeleteWebhookFilter(c *gin.Context) {
+	webhook, ok := wc.getWebhook(c)
+	if !ok {
+		return
+	}
+	result := database.DB.
+		Where("id = ? AND webhook_id = ?", c.Param("filterId"), webhook.ID).
+		Delete(&models.WebhookFilter{})
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "filter not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "filter deleted"})
+}
+
+// GetCapabilities lists the webhook event catalog and the default
+// subscription applied to webhooks created without an events array.
+func (wc *WebhookController) GetCapabilities(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{
+		"events":         services.EventCatalog,
+		"default_events": config.Get().DefaultWebhookEvents,
+	}})
+}
+
+// ExportWebhooks returns an instance's webhook configurations in a
+// portable shape for recreating them elsewhere. Secrets are redacted
+// unless ?include_secrets=true; redacted entries carry has_secret so the
+// importer knows to supply one.
+func (wc *WebhookController) ExportWebhooks(c *gin.Context) {
+	instanceID := c.Param("instanceId")
+	includeSecrets := c.Query("include_secrets") == "true"
+
+	var webhooks []models.Webhook
+	if err := database.DB.Where("instance_id = ?", instanceID).Find(&webhooks).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	items := make([]models.WebhookExportItem, 0, len(webhooks))
+	for i := range webhooks {
+		webhook := webhooks[i]
+		var events []string
+		json.Unmarshal([]byte(webhook.Events), &events)
+		active := webhook.Active
+		item := models.WebhookExportItem{
+			URL:    webhook.URL,
+			Events: events,
+			Active: &active,
+		}
+		if includeSecrets {
+			item.Secret = webhook.Secret
+		} else if webhook.Secret != "" {
+			item.HasSecret = true
+		}
+		if webhook.InsecureTLS {
+			insecure := true
+			item.InsecureTLS = &insecure
+		}
+		if webhook.CACertPath != "" {
+			path := webhook.CACertPath
+			item.CACertPath = &path
+		}
+		if webhook.Transform != "" {
+			transform := webhook.Transform
+			item.Transform = &transform
+		}
+		if webhook.SignatureAlgorithm != "" {
+			algorithm := webhook.SignatureAlgorithm
+			item.SignatureAlgorithm = &algorithm
+		}
+		var filters []models.WebhookFilter
+		database.DB.Where("webhook_id = ?", webhook.ID).Find(&filters)
+		for _, f := range filters {
+			item.Filters = append(item.Filters, models.WebhookFilterSpec{
+				Field:    f.Field,
+				Operator: f.Operator,
+				Value:    f.Value,
+			})
+		}
+		items = append(items, item)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{
+		"instance_id":      instanceID,
+		"webhooks":         items,
+		"count":            len(items),
+		"secrets_included": includeSecrets,
+	}})
+}
+
+// ImportWebhooks recreates exported webhook configurations on an
+// instance, regenerating every ID. Items are validated like the
+// configure endpoint; dry_run reports what would be created without
+// writing. A 207 response means some items failed validation.
+func (wc *WebhookController) ImportWebhooks(c *gin.Context) {
+	var req models.ImportWebhooksRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	instanceID := c.Param("instanceId")
+	results := make([]gin.H, 0, len(req.Webhooks))
+	created := 0
+	for i := range req.Webhooks {
+		item := req.Webhooks[i]
+		result := gin.H{"url": item.URL, "success": false}
+		webhook, filters, err := buildImportedWebhook(instanceID, &item)
+		switch {
+		case err != nil:
+			result["error"] = err.Error()
+		case req.DryRun:
+			result["success"] = true
+			result["would_create"] = true
+			created++
+		default:
+			if err := database.DB.Create(webhook).Error; err != nil {
+				result["error"] = err.Error()
+				break
+			}
+			for j := range filters {
+				database.DB.Create(&filters[j])
+			}
+			result["success"] = true
+			result["webhook_id"] = webhook.ID
+			created++
+		}
+		results = append(results, result)
+	}
+
+	status := http.StatusCreated
+	if req.DryRun {
+		status = http.StatusOK
+	} else if created < len(req.Webhooks) {
+		status = http.StatusMultiStatus
+	}
+	c.JSON(status, gin.H{"success": created == len(req.Webhooks), "data": gin.H{
+		"created": created,
+		"total":   len(req.Webhooks),
+		"dry_run": req.DryRun,
+		"results": results,
+	}})
+}
+
+// buildImportedWebhook validates one import item and assembles the
+// webhook and filter rows it would create. Unlike the configure
+// endpoint's helpers it returns errors instead of writing responses, so
+// a bad item fails alone inside a batch.
+func buildImportedWebhook(instanceID string, item *models.WebhookExportItem) (*models.Webhook, []models.WebhookFilter, error) {
+	if _, err := url.ParseRequestURI(item.URL); err != nil {
+		return nil, nil, errors.New("invalid webhook URL")
+	}
+	subscribed := item.Events
+	if len(subscribed) == 0 {
+		subscribed = config.Get().DefaultWebhookEvents
+	}
+	if len(subscribed) == 0 {
+		return nil, nil, errors.New("events is required and no default subscription is configured")
+	}
+	for _, ev := range subscribed {
+		if ev != "*" && !services.KnownEventType(ev) {
+			return nil, nil, errors.New("unknown event type: " + ev)
+		}
+	}
+	events, err := json.Marshal(subscribed)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	webhook := &models.Webhook{
+		ID:         uuid.NewString(),
+		InstanceID: instanceID,
+		URL:        item.URL,
+		Secret:     item.Secret,
+		Events:     string(events),
+		Active:     true,
+	}
+	if item.Active != nil {
+		webhook.Active = *item.Active
+	}
+	if item.InsecureTLS != nil {
+		webhook.InsecureTLS = *item.InsecureTLS
+	}
+	if item.CACertPath != nil && *item.CACertPath != "" {
+		pem, err := os.ReadFile(*item.CACertPath)
+		if err != nil {
+			return nil, nil, errors.New("reading ca_cert_path: " + err.Error())
+		}
+		if !x509.NewCertPool().AppendCertsFromPEM(pem) {
+			return nil, nil, errors.New("ca_cert_path contains no PEM certificates")
+		}
+		webhook.CACertPath = *item.CACertPath
+	}
+	if item.Transform != nil && *item.Transform != "" {
+		if _, err := template.New("transform").Parse(*item.Transform); err != nil {
+			return nil, nil, errors.New("invalid transform template: " + err.Error())
+		}
+		webhook.Transform = *item.Transform
+	}
+	if item.SignatureAlgorithm != nil && *item.SignatureAlgorithm != "" {
+		if !services.ValidSignatureAlgorithm(*item.SignatureAlgorithm) {
+			return nil, nil, errors.New("signature_algorithm must be sha256 or sha512")
+		}
+		webhook.SignatureAlgorithm = *item.SignatureAlgorithm
+	}
+
+	filters := make([]models.WebhookFilter, 0, len(item.Filters))
+	for _, f := range item.Filters {
+		switch f.Operator {
+		case "", "equals", "not_equals", "contains":
+		default:
+			return nil, nil, errors.New("filter operator must be equals, not_equals or contains")
+		}
+		if f.Field == "" || f.Value == "" {
+			return nil, nil, errors.New("filter field and value are required")
+		}
+		filters = append(filters, models.WebhookFilter{
+			WebhookID: webhook.ID,
+			Field:     f.Field,
+			Operator:  f.Operator,
+			Value:     f.Value,
+		})
+	}
+	return webhook, filters, nil
+}
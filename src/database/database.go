@@ -0,0 +1,86 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/glebarez/sqlite"
+	"go.mau.fi/whatsmeow/store/sqlstore"
+	waLog "go.mau.fi/whatsmeow/util/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/Layoxd/whatsapiGo/src/config"
+	"github.com/Layoxd/whatsapiGo/src/models"
+)
+
+// DB is the shared GORM handle for application data.
+var DB *gorm.DB
+
+// Container is the whatsmeow session store shared by all instances.
+var Container *sqlstore.Container
+
+// WhatsmeowDB is the raw handle to the whatsmeow session database, used
+// for read-only lookups the sqlstore API does not expose.
+var WhatsmeowDB *sql.DB
+
+// Init opens both databases and migrates the application schema.
+func Init(cfg *config.Config) error {
+	db, err := gorm.Open(sqlite.Open(cfg.DBPath), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		return fmt.Errorf("opening app database: %w", err)
+	}
+	DB = db
+
+	if err := Migrate(db); err != nil {
+		return fmt.Errorf("migrating app database: %w", err)
+	}
+
+	// The whatsmeow container shares the pure-Go sqlite driver but keeps
+	// its own file so session keys stay separate from application data.
+	waDB, err := sql.Open("sqlite", cfg.WhatsmeowDBPath+"?_pragma=foreign_keys(1)")
+	if err != nil {
+		return fmt.Errorf("opening whatsmeow database: %w", err)
+	}
+	container := sqlstore.NewWithDB(waDB, "sqlite3", waLog.Stdout("SQLStore", cfg.LogLevel, true))
+	if err := container.Upgrade(); err != nil {
+		return fmt.Errorf("migrating whatsmeow database: %w", err)
+	}
+	Container = container
+	WhatsmeowDB = waDB
+	return nil
+}
+
+// MigratedModels lists every model covered by the auto-migrations, in
+// migration order.
+func MigratedModels() []interface{} {
+	return []interface{}{
+		&models.Instance{},
+		&models.Message{},
+		&models.MessageReceipt{},
+		&models.GroupDeliveryStatus{},
+		&models.Status{},
+		&models.Webhook{},
+		&models.WebhookFilter{},
+		&models.WebhookLog{},
+		&models.WebhookMute{},
+		&models.GroupInviteReset{},
+		&models.Label{},
+		&models.ChatLabel{},
+		&models.MessageTemplate{},
+		&models.SendJob{},
+		&models.CallSettings{},
+		&models.CallLog{},
+		&models.EventLog{},
+		&models.Poll{},
+		&models.PollVote{},
+		&models.APIKey{},
+	}
+}
+
+// Migrate applies the GORM auto-migrations for every model.
+func Migrate(db *gorm.DB) error {
+	return db.AutoMigrate(MigratedModels()...)
+}
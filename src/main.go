@@ -0,0 +1,32 @@
+package main
+
+import (
+	"log"
+
+	"github.com/Layoxd/whatsapiGo/src/config"
+	"github.com/Layoxd/whatsapiGo/src/database"
+	"github.com/Layoxd/whatsapiGo/src/routes"
+	"github.com/Layoxd/whatsapiGo/src/services"
+)
+
+func main() {
+	cfg := config.Load()
+
+	if err := database.Init(cfg); err != nil {
+		log.Fatalf("database init failed: %v", err)
+	}
+
+	webhookService := services.NewWebhookService(database.DB)
+	router, instanceController := routes.Setup(webhookService)
+
+	if restored, err := instanceController.RestoreInstances(); err != nil {
+		log.Printf("restoring instances: %v", err)
+	} else if restored > 0 {
+		log.Printf("restored %d instance(s) from the database", restored)
+	}
+
+	log.Printf("whatsapiGo listening on :%s", cfg.Port)
+	if err := router.Run(":" + cfg.Port); err != nil {
+		log.Fatalf("server exited: %v", err)
+	}
+}
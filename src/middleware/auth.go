@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Layoxd/whatsapiGo/src/config"
+	"github.com/Layoxd/whatsapiGo/src/database"
+	"github.com/Layoxd/whatsapiGo/src/models"
+)
+
+// APIKeyAuth rejects requests that do not carry a valid API key in the
+// X-API-Key header. Both the key from the environment and non-revoked
+// keys stored in the database are accepted, so keys can be rotated at
+// runtime without a restart. When no env key is configured and no
+// database keys exist, authentication is disabled (useful for local
+// development only).
+func APIKeyAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cfg := config.Get()
+		provided := c.GetHeader("X-API-Key")
+		if cfg.APIKey != "" && provided == cfg.APIKey {
+			c.Next()
+			return
+		}
+		if provided != "" && databaseKeyValid(provided) {
+			c.Next()
+			return
+		}
+		if cfg.APIKey == "" && !databaseKeysExist() {
+			c.Next()
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "invalid or missing API key",
+		})
+	}
+}
+
+// MasterKeyAuth protects the key-management endpoints with the master
+// key from the environment, sent in X-Master-Key. Unlike APIKeyAuth it
+// never falls open: with no master key configured the endpoints are
+// disabled.
+func MasterKeyAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cfg := config.Get()
+		if cfg.MasterKey == "" {
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+				"success": false,
+				"error":   "key management is disabled: no master key configured",
+			})
+			return
+		}
+		if c.GetHeader("X-Master-Key") != cfg.MasterKey {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"success": false,
+				"error":   "invalid or missing master key",
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// HashAPIKey returns the hex SHA-256 of a key, the form stored in the
+// database.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// databaseKeyValid reports whether a non-revoked stored key matches the
+// provided plaintext.
+func databaseKeyValid(provided string) bool {
+	var count int64
+	err := database.DB.Model(&models.APIKey{}).
+		Where("key_hash = ? AND revoked = ?", HashAPIKey(provided), false).
+		Count(&count).Error
+	return err == nil && count > 0
+}
+
+// databaseKeysExist reports whether any non-revoked keys are stored.
+func databaseKeysExist() bool {
+	var count int64
+	err := database.DB.Model(&models.APIKey{}).
+		Where("revoked = ?", false).
+		Count(&count).Error
+	return err == nil && count > 0
+}
@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.mau.fi/whatsmeow/types"
+
+	"github.com/Layoxd/whatsapiGo/src/utils"
+)
+
+// contextJIDKey is the gin context key under which ValidateJID stores the
+// parsed path JID.
+const contextJIDKey = "parsedJID"
+
+// ValidateJID returns middleware that parses and normalizes the named JID
+// path parameter (":jid" or ":groupId") before the handler runs. Handlers
+// behind it read the result with JIDFromContext and never see malformed
+// input; bad values get a uniform 400.
+func ValidateJID(param string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		jid, ok := parseJIDParam(param, c.Param(param))
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "invalid " + param + " path parameter",
+			})
+			return
+		}
+		c.Set(contextJIDKey, jid)
+		c.Next()
+	}
+}
+
+// JIDFromContext returns the JID stored by a ValidateJID middleware on
+// this request. It is the zero JID if no such middleware ran.
+func JIDFromContext(c *gin.Context) types.JID {
+	value, _ := c.Get(contextJIDKey)
+	jid, _ := value.(types.JID)
+	return jid
+}
+
+// parseJIDParam converts a path value into a JID. Full JIDs are parsed and
+// checked against the expected server; bare values are treated as a phone
+// number for ":jid" and a group ID for ":groupId".
+func parseJIDParam(param, value string) (types.JID, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return types.EmptyJID, false
+	}
+	if strings.ContainsRune(value, '@') {
+		jid, err := types.ParseJID(value)
+		if err != nil || jid.User == "" {
+			return types.EmptyJID, false
+		}
+		if param == "groupId" && jid.Server != types.GroupServer {
+			return types.EmptyJID, false
+		}
+		return jid, true
+	}
+	if param == "groupId" {
+		return utils.GroupJID(value), true
+	}
+	phone := utils.NormalizePhone(value)
+	if phone == "" {
+		return types.EmptyJID, false
+	}
+	for _, r := range phone {
+		if r < '0' || r > '9' {
+			return types.EmptyJID, false
+		}
+	}
+	return utils.UserJID(phone), true
+}
@@ -0,0 +1,393 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Instance is the persisted record of a WhatsApp instance. The live
+// whatsmeow client is held in memory by the instance controller; this row
+// keeps the metadata that should survive restarts.
+type Instance struct {
+	ID        string `gorm:"primaryKey" json:"id"`
+	Name      string `json:"name"`
+	Phone     string `json:"phone"`
+	UserJID   string `json:"user_jid"`
+	DeviceJID string `json:"device_jid"`
+	PushName  string `json:"push_name"`
+	Status    string `json:"status"`
+	// Metadata is free-form JSON supplied at creation, kept for the
+	// caller's own bookkeeping (tenant IDs, provisioning tags).
+	Metadata string `json:"metadata,omitempty"`
+	// AutoRead marks every incoming message as read automatically. Off
+	// by default: senders see blue ticks for messages no human has seen.
+	AutoRead bool `json:"auto_read"`
+	// AutoDownloadMedia downloads incoming media up to the configured
+	// size cap and stores it under the media directory, so webhook
+	// consumers get a local URL instead of an encrypted-media bundle.
+	AutoDownloadMedia bool `json:"auto_download_media"`
+	// OSName and Platform form the device fingerprint presented to
+	// WhatsApp at pairing; empty means whatsmeow's defaults.
+	OSName   string `json:"os_name,omitempty"`
+	Platform string `json:"platform,omitempty"`
+	// PresenceKeepalive keeps the account visibly online by re-sending
+	// available presence periodically. Off by default: while enabled,
+	// contacts see the account as online around the clock.
+	PresenceKeepalive bool `json:"presence_keepalive"`
+	// ReadReceiptsDisabled mirrors the account's read-receipt privacy
+	// setting as last observed, so mark-read callers can be warned even
+	// before the privacy cache is populated.
+	ReadReceiptsDisabled bool `json:"read_receipts_disabled"`
+	// WebhookConcurrency caps concurrent webhook deliveries for this
+	// instance, overriding the global config; zero means the global value.
+	WebhookConcurrency int `json:"webhook_concurrency"`
+	// WebhookTimeoutSeconds overrides the per-delivery timeout for this
+	// instance's webhooks; zero means the global value.
+	WebhookTimeoutSeconds int `json:"webhook_timeout_seconds"`
+	// LastError is the most recent session-level error (stream error,
+	// temporary ban, outdated client) with when it was observed.
+	LastError   string         `json:"last_error,omitempty"`
+	LastErrorAt *time.Time     `json:"last_error_at,omitempty"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// Instance status values. Banned and error are distinct from a plain
+// disconnect so operators can tell the difference at a glance.
+const (
+	InstanceStatusCreated      = "created"
+	InstanceStatusQRPending    = "qr_pending"
+	InstanceStatusConnected    = "connected"
+	InstanceStatusDisconnected = "disconnected"
+	InstanceStatusBanned       = "banned"
+	InstanceStatusError        = "error"
+)
+
+// Message is a stored chat message, either received from WhatsApp or sent
+// through the API. Media fields hold the encrypted-media bundle so the
+// payload can be downloaded later.
+type Message struct {
+	ID         uint   `gorm:"primaryKey" json:"id"`
+	InstanceID string `gorm:"index" json:"instance_id"`
+	MessageID  string `gorm:"index" json:"message_id"`
+	ChatJID    string `gorm:"index" json:"chat_jid"`
+	SenderJID  string `json:"sender_jid"`
+	FromMe     bool   `json:"from_me"`
+	Type       string `json:"type"`
+	Content    string `json:"content"`
+	// Encrypted media bundle (set for image/video/audio/document messages).
+	MediaURL      string         `json:"media_url,omitempty"`
+	DirectPath    string         `json:"direct_path,omitempty"`
+	MediaKey      []byte         `json:"media_key,omitempty"`
+	FileEncSHA256 []byte         `json:"file_enc_sha256,omitempty"`
+	FileSHA256    []byte         `json:"file_sha256,omitempty"`
+	FileLength    uint64         `json:"file_length,omitempty"`
+	Mimetype      string         `json:"mimetype,omitempty"`
+	Status        string         `json:"status"`
+	Starred       bool           `json:"starred"`
+	Timestamp     time.Time      `json:"timestamp"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// Message status values.
+const (
+	MessageStatusSent      = "sent"
+	MessageStatusDelivered = "delivered"
+	MessageStatusRead      = "read"
+	MessageStatusReceived  = "received"
+)
+
+// MessageReceipt records a single delivery/read receipt for a message,
+// one row per participant per receipt type.
+type MessageReceipt struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	InstanceID string    `gorm:"index" json:"instance_id"`
+	MessageID  string    `gorm:"index" json:"message_id"`
+	ChatJID    string    `json:"chat_jid"`
+	SenderJID  string    `json:"sender_jid"`
+	Type       string    `json:"type"`
+	Timestamp  time.Time `json:"timestamp"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// GroupDeliveryStatus aggregates the per-recipient receipts of one
+// outgoing group message into summary counts, updated as receipts arrive.
+type GroupDeliveryStatus struct {
+	ID                uint      `gorm:"primaryKey" json:"id"`
+	InstanceID        string    `gorm:"index" json:"instance_id"`
+	MessageID         string    `gorm:"index" json:"message_id"`
+	GroupJID          string    `json:"group_jid"`
+	TotalParticipants int       `json:"total_participants"`
+	DeliveredCount    int       `json:"delivered_count"`
+	ReadCount         int       `json:"read_count"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// Poll is a poll sent through the API, kept so incoming votes can be
+// decoded back to option names and aggregated later.
+type Poll struct {
+	ID         uint   `gorm:"primaryKey" json:"id"`
+	InstanceID string `gorm:"index" json:"instance_id"`
+	MessageID  string `gorm:"index" json:"message_id"`
+	ChatJID    string `json:"chat_jid"`
+	Question   string `json:"question"`
+	// Options is a JSON-encoded list of the option names in display order.
+	Options string `json:"options"`
+	// SelectableCount is how many options a voter may pick; 0 and 1 both
+	// mean single-select.
+	SelectableCount int       `json:"selectable_count"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// PollVote holds the latest vote of one voter on a poll. WhatsApp votes
+// replace earlier ones, so there is one row per voter per poll.
+type PollVote struct {
+	ID            uint   `gorm:"primaryKey" json:"id"`
+	InstanceID    string `gorm:"index" json:"instance_id"`
+	PollMessageID string `gorm:"index" json:"poll_message_id"`
+	VoterJID      string `json:"voter_jid"`
+	// SelectedOptions is a JSON-encoded list of the chosen option names;
+	// empty list means the vote was retracted.
+	SelectedOptions string    `json:"selected_options"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// Status is a stored status (story) update observed from a contact, kept
+// long enough to allow media download before WhatsApp expires it.
+type Status struct {
+	ID            uint           `gorm:"primaryKey" json:"id"`
+	InstanceID    string         `gorm:"index" json:"instance_id"`
+	StatusID      string         `gorm:"index" json:"status_id"`
+	AuthorJID     string         `json:"author_jid"`
+	Type          string         `json:"type"`
+	Content       string         `json:"content"`
+	MediaURL      string         `json:"media_url,omitempty"`
+	DirectPath    string         `json:"direct_path,omitempty"`
+	MediaKey      []byte         `json:"media_key,omitempty"`
+	FileEncSHA256 []byte         `json:"file_enc_sha256,omitempty"`
+	FileSHA256    []byte         `json:"file_sha256,omitempty"`
+	FileLength    uint64         `json:"file_length,omitempty"`
+	Mimetype      string         `json:"mimetype,omitempty"`
+	Timestamp     time.Time      `json:"timestamp"`
+	ExpiresAt     time.Time      `json:"expires_at"`
+	CreatedAt     time.Time      `json:"created_at"`
+	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// Webhook is a configured webhook endpoint for an instance.
+type Webhook struct {
+	ID         string `gorm:"primaryKey" json:"id"`
+	InstanceID string `gorm:"index" json:"instance_id"`
+	URL        string `json:"url"`
+	Secret     string `json:"-"`
+	// Events is a JSON-encoded list of subscribed event types; "*"
+	// subscribes to everything.
+	Events string `json:"events"`
+	Active bool   `json:"active"`
+	// InsecureTLS disables certificate verification for deliveries to
+	// this webhook. Only meant for internal endpoints during development.
+	InsecureTLS bool `json:"insecure_tls"`
+	// CACertPath points to a PEM bundle to trust instead of the system
+	// roots, for consumers behind an internal CA.
+	CACertPath string `json:"ca_cert_path,omitempty"`
+	// Transform is an optional Go text/template applied to the delivery
+	// body; it renders with .Event and .Data. Empty sends the default
+	// payload.
+	Transform string `json:"transform,omitempty"`
+	// SignatureAlgorithm selects the HMAC used for X-Webhook-Signature:
+	// sha256 (the default when empty) or sha512.
+	SignatureAlgorithm string `json:"signature_algorithm,omitempty"`
+	// PresenceThrottleSeconds bounds contact.presence deliveries to one
+	// per contact per window. Zero uses the 10-second default; negative
+	// disables throttling.
+	PresenceThrottleSeconds int            `json:"presence_throttle_seconds,omitempty"`
+	CreatedAt               time.Time      `json:"created_at"`
+	UpdatedAt               time.Time      `json:"updated_at"`
+	DeletedAt               gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// WebhookFilter narrows which events a webhook receives, e.g. only events
+// for a particular chat. Filters are ANDed during dispatch.
+type WebhookFilter struct {
+	ID        uint   `gorm:"primaryKey" json:"id"`
+	WebhookID string `gorm:"index" json:"webhook_id"`
+	Field     string `json:"field"`
+	Operator  string `json:"operator"`
+	Value     string `json:"value"`
+}
+
+// WebhookLog records one delivery attempt chain for an event to a webhook.
+type WebhookLog struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	WebhookID  string    `gorm:"index" json:"webhook_id"`
+	InstanceID string    `gorm:"index" json:"instance_id"`
+	EventType  string    `json:"event_type"`
+	Payload    string    `json:"payload"`
+	StatusCode int       `json:"status_code"`
+	Success    bool      `json:"success"`
+	Attempts   int       `json:"attempts"`
+	Error      string    `json:"error,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// WebhookMute temporarily silences one event type for a webhook during
+// dispatch. Expired rows are purged lazily when mutes are read.
+type WebhookMute struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	WebhookID string    `gorm:"index" json:"webhook_id"`
+	EventType string    `json:"event_type"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CallSettings stores the per-instance incoming-call behaviour.
+type CallSettings struct {
+	ID         uint   `gorm:"primaryKey" json:"id"`
+	InstanceID string `gorm:"uniqueIndex" json:"instance_id"`
+	AutoReject bool   `json:"auto_reject"`
+	// Whitelist is a JSON-encoded list of numbers/JIDs never auto-rejected.
+	Whitelist string `json:"whitelist"`
+	// CustomMessages is a JSON object of messages sent after rejecting;
+	// the "default" key is used when no more specific entry matches.
+	CustomMessages string `json:"custom_messages"`
+	// RejectMessageCooldown is how many seconds to wait before sending the
+	// explanation message to the same caller again. Calls are still
+	// rejected during the cooldown; 0 sends the message on every call.
+	RejectMessageCooldown int `json:"reject_message_cooldown"`
+	// RejectAudioCalls/RejectVideoCalls reject only that call type; they
+	// are checked in addition to AutoReject, which rejects everything.
+	RejectAudioCalls bool `json:"reject_audio_calls"`
+	RejectVideoCalls bool `json:"reject_video_calls"`
+	// Optional daily window (HH:MM, 24h) outside which calls are rejected
+	// regardless of AutoReject. Empty disables the schedule.
+	RejectScheduleStart string    `json:"reject_schedule_start"`
+	RejectScheduleEnd   string    `json:"reject_schedule_end"`
+	CreatedAt           time.Time `json:"created_at"`
+	UpdatedAt           time.Time `json:"updated_at"`
+}
+
+// GroupInviteReset records an invite-link rotation observed via group
+// update events, so link changes can be audited later.
+type GroupInviteReset struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	InstanceID string    `gorm:"index" json:"instance_id"`
+	GroupJID   string    `gorm:"index" json:"group_jid"`
+	ResetBy    string    `json:"reset_by"`
+	NewLink    string    `json:"new_link"`
+	Timestamp  time.Time `json:"timestamp"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Label is a business label, either created through the API or synced
+// from the account's app state.
+type Label struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	InstanceID string    `gorm:"index" json:"instance_id"`
+	LabelID    string    `gorm:"index" json:"label_id"`
+	Name       string    `json:"name"`
+	Color      int32     `json:"color"`
+	Deleted    bool      `json:"deleted"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// ChatLabel associates a label with a chat.
+type ChatLabel struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	InstanceID string    `gorm:"index" json:"instance_id"`
+	ChatJID    string    `gorm:"index" json:"chat_jid"`
+	LabelID    string    `gorm:"index" json:"label_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// MessageTemplate is a reusable message text with {{placeholder}}
+// parameters that are substituted at send time.
+type MessageTemplate struct {
+	ID         string         `gorm:"primaryKey" json:"id"`
+	InstanceID string         `gorm:"index" json:"instance_id"`
+	Name       string         `json:"name"`
+	Content    string         `json:"content"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// SendJob tracks a bulk or scheduled send so operators can watch
+// progress and cancel what is still pending. Rows outlive the process;
+// jobs that were mid-flight during a restart are marked interrupted.
+type SendJob struct {
+	ID         string `gorm:"primaryKey" json:"id"`
+	InstanceID string `gorm:"index" json:"instance_id"`
+	// Type is "bulk" or "scheduled".
+	Type   string `json:"type"`
+	Status string `json:"status"`
+	Total  int    `json:"total"`
+	Sent   int    `json:"sent"`
+	Failed int    `json:"failed"`
+	// Payload is the JSON-encoded send request driving the job, kept so
+	// pending schedules can be re-armed after a restart.
+	Payload     string     `json:"-"`
+	ScheduledAt *time.Time `json:"scheduled_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// Send job lifecycle states.
+const (
+	JobStatusPending     = "pending"
+	JobStatusRunning     = "running"
+	JobStatusCompleted   = "completed"
+	JobStatusCancelled   = "cancelled"
+	JobStatusFailed      = "failed"
+	JobStatusInterrupted = "interrupted"
+)
+
+// CallLog records an incoming call and what was done with it.
+type CallLog struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	InstanceID string    `gorm:"index" json:"instance_id"`
+	CallID     string    `json:"call_id"`
+	CallerJID  string    `json:"caller_jid"`
+	IsVideo    bool      `json:"is_video"`
+	Action     string    `json:"action"`
+	Timestamp  time.Time `json:"timestamp"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Call log actions.
+const (
+	CallActionReceived         = "received"
+	CallActionRejected         = "rejected"
+	CallActionWhitelisted      = "whitelisted"
+	CallActionScheduleRejected = "schedule_rejected"
+)
+
+// EventLog is a lightweight audit record of every event processed for an
+// instance, queryable through GET /logs.
+type EventLog struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	InstanceID string    `gorm:"index" json:"instance_id"`
+	EventType  string    `gorm:"index" json:"event_type"`
+	Payload    string    `json:"payload"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// APIKey is an API key stored in the database so keys can be added and
+// revoked at runtime without restarting the server. Only the SHA-256 hash
+// of the key is stored; the plaintext is returned once on creation.
+type APIKey struct {
+	ID          string     `gorm:"primaryKey" json:"id"`
+	Label       string     `json:"label"`
+	KeyHash     string     `gorm:"index" json:"-"`
+	Fingerprint string     `json:"fingerprint"`
+	Revoked     bool       `json:"revoked"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
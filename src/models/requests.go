@@ -0,0 +1,487 @@
+package models
+
+// CreateInstanceRequest creates a new WhatsApp instance.
+type CreateInstanceRequest struct {
+	Name string `json:"name" binding:"required"`
+	// OSName and Platform set the device fingerprint presented to
+	// WhatsApp at pairing. Platform must be one of the allowed
+	// browser/desktop names; both default to whatsmeow's values.
+	OSName   string `json:"os_name"`
+	Platform string `json:"platform"`
+}
+
+// BatchCreateInstancesRequest provisions several instances in one call.
+type BatchCreateInstancesRequest struct {
+	Instances []BatchCreateInstanceItem `json:"instances" binding:"required,min=1,max=50"`
+}
+
+// BatchCreateInstanceItem describes one instance in a batch-create
+// request.
+type BatchCreateInstanceItem struct {
+	Name     string                 `json:"name" binding:"required"`
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+	Webhook  *BatchWebhookConfig    `json:"webhook,omitempty"`
+}
+
+// BatchWebhookConfig is the minimal webhook attached to a batch-created
+// instance. Advanced delivery options (TLS, transforms, signing) are set
+// afterwards through the webhook endpoints.
+type BatchWebhookConfig struct {
+	URL    string   `json:"url" binding:"required"`
+	Secret string   `json:"secret,omitempty"`
+	Events []string `json:"events,omitempty"`
+}
+
+// TextSegment is one styled run of text; Styles are WhatsApp formatting
+// names (bold, italic, strikethrough, monospace).
+type TextSegment struct {
+	Text   string   `json:"text" binding:"required"`
+	Styles []string `json:"styles,omitempty"`
+}
+
+// SendTextMessageRequest sends a plain text message. Either Message or
+// Segments must be set; Segments are rendered into WhatsApp markdown.
+type SendTextMessageRequest struct {
+	InstanceID string `json:"instance_id" binding:"required"`
+	Phone      string `json:"phone"`
+	IsGroup    bool   `json:"is_group"`
+	// RecipientJID targets a raw JID (user, group or newsletter)
+	// directly, bypassing phone formatting; when set, Phone and IsGroup
+	// are ignored.
+	RecipientJID string `json:"recipient_jid,omitempty"`
+	Message      string `json:"message"`
+	// Format selects optional rich-text handling: "markdown" validates
+	// that formatting markers are balanced before sending.
+	Format   string        `json:"format,omitempty"`
+	Segments []TextSegment `json:"segments,omitempty"`
+	// ReplyTo quotes an earlier message. By default it is looked up in
+	// the target chat; ReplyToChat quotes a message from another chat
+	// (cross-chat quote), and ReplyToSender names the original sender,
+	// which is required when the quoted chat is a group the message
+	// store does not know the message from.
+	ReplyTo       string `json:"reply_to,omitempty"`
+	ReplyToChat   string `json:"reply_to_chat,omitempty"`
+	ReplyToSender string `json:"reply_to_sender,omitempty"`
+	// MentionAll mentions every group participant; only valid when the
+	// recipient is a group.
+	MentionAll bool `json:"mention_all,omitempty"`
+	// ClientMessageID supplies the WhatsApp message ID for correlation;
+	// 16-64 uppercase hex characters, unique per instance within 24h.
+	ClientMessageID string `json:"client_message_id,omitempty"`
+	// EphemeralSeconds makes this one message disappear after the given
+	// duration; WhatsApp accepts the chat-timer durations (24h, 7d, 90d).
+	EphemeralSeconds uint32 `json:"ephemeral_seconds,omitempty"`
+	// RetryTransient overrides the SEND_RETRY_TRANSIENT config for this
+	// send: retry briefly on transient connection errors before failing.
+	RetryTransient *bool `json:"retry_transient,omitempty"`
+	// AdContext attaches click-to-WhatsApp ad attribution to the message.
+	AdContext *AdContextRequest `json:"ad_context,omitempty"`
+	// TimeoutSeconds overrides the configured send timeout for this
+	// call; zero uses the server default.
+	TimeoutSeconds int `json:"timeout_seconds,omitempty"`
+	// WaitDelivery blocks the response until the delivered receipt
+	// arrives or WaitDeliverySeconds passes, adding up to that much
+	// latency to the call. On timeout the message is still sent.
+	WaitDelivery        bool `json:"wait_delivery,omitempty"`
+	WaitDeliverySeconds int  `json:"wait_delivery_seconds,omitempty"`
+}
+
+// AdContextRequest is the click-to-WhatsApp ad attribution attached to an
+// outgoing message as an external ad reply.
+type AdContextRequest struct {
+	Title string `json:"title,omitempty"`
+	Body  string `json:"body,omitempty"`
+	// MediaType is "image" or "video"; empty means no ad media.
+	MediaType    string `json:"media_type,omitempty"`
+	ThumbnailURL string `json:"thumbnail_url,omitempty"`
+	MediaURL     string `json:"media_url,omitempty"`
+	SourceType   string `json:"source_type,omitempty"`
+	SourceID     string `json:"source_id,omitempty"`
+	SourceURL    string `json:"source_url,omitempty"`
+	CtwaClid     string `json:"ctwa_clid,omitempty"`
+	Ref          string `json:"ref,omitempty"`
+}
+
+// SendMediaMessageRequest sends an image/video/audio/document message.
+// Media is provided as base64 in the request body.
+type SendMediaMessageRequest struct {
+	InstanceID   string `json:"instance_id" binding:"required"`
+	Phone        string `json:"phone"`
+	IsGroup      bool   `json:"is_group"`
+	RecipientJID string `json:"recipient_jid,omitempty"`
+	MediaBase64  string `json:"media_base64" binding:"required"`
+	Mimetype     string `json:"mimetype" binding:"required"`
+	Caption      string `json:"caption,omitempty"`
+	FileName     string `json:"file_name,omitempty"`
+	// IsPTT marks an audio message as a voice note.
+	IsPTT bool `json:"is_ptt,omitempty"`
+	// ClientMessageID mirrors the text endpoint: a caller-supplied
+	// WhatsApp message ID for correlation.
+	ClientMessageID string `json:"client_message_id,omitempty"`
+	// EphemeralSeconds mirrors the text endpoint: a per-message
+	// disappearing duration (24h, 7d or 90d in seconds).
+	EphemeralSeconds uint32 `json:"ephemeral_seconds,omitempty"`
+	// RetryTransient mirrors the text endpoint: retry briefly on
+	// transient connection errors before failing.
+	RetryTransient *bool `json:"retry_transient,omitempty"`
+	TimeoutSeconds int   `json:"timeout_seconds,omitempty"`
+	// WaitDelivery mirrors the text endpoint: wait for the delivered
+	// receipt before responding, at the cost of added latency.
+	WaitDelivery        bool `json:"wait_delivery,omitempty"`
+	WaitDeliverySeconds int  `json:"wait_delivery_seconds,omitempty"`
+}
+
+// SendLocationMessageRequest sends a static location pin.
+type SendLocationMessageRequest struct {
+	InstanceID     string  `json:"instance_id" binding:"required"`
+	Phone          string  `json:"phone"`
+	IsGroup        bool    `json:"is_group"`
+	RecipientJID   string  `json:"recipient_jid,omitempty"`
+	Latitude       float64 `json:"latitude" binding:"required"`
+	Longitude      float64 `json:"longitude" binding:"required"`
+	Name           string  `json:"name,omitempty"`
+	Address        string  `json:"address,omitempty"`
+	TimeoutSeconds int     `json:"timeout_seconds,omitempty"`
+}
+
+// SendLiveLocationMessageRequest starts sharing a live location.
+type SendLiveLocationMessageRequest struct {
+	InstanceID   string  `json:"instance_id" binding:"required"`
+	Phone        string  `json:"phone"`
+	IsGroup      bool    `json:"is_group"`
+	RecipientJID string  `json:"recipient_jid,omitempty"`
+	Latitude     float64 `json:"latitude" binding:"required"`
+	Longitude    float64 `json:"longitude" binding:"required"`
+	// DurationSeconds is how long the live location stays active.
+	DurationSeconds int    `json:"duration_seconds" binding:"required"`
+	Caption         string `json:"caption,omitempty"`
+	TimeoutSeconds  int    `json:"timeout_seconds,omitempty"`
+}
+
+// AlbumItem is one media entry of an album message.
+type AlbumItem struct {
+	MediaBase64 string `json:"media_base64" binding:"required"`
+	Mimetype    string `json:"mimetype" binding:"required"`
+	Caption     string `json:"caption,omitempty"`
+}
+
+// SendAlbumRequest sends several images/videos as one grouped album.
+type SendAlbumRequest struct {
+	InstanceID     string      `json:"instance_id" binding:"required"`
+	Phone          string      `json:"phone"`
+	IsGroup        bool        `json:"is_group"`
+	RecipientJID   string      `json:"recipient_jid,omitempty"`
+	Caption        string      `json:"caption,omitempty"`
+	Items          []AlbumItem `json:"items" binding:"required"`
+	TimeoutSeconds int         `json:"timeout_seconds,omitempty"`
+}
+
+// SendContactMessageRequest shares a contact card.
+type SendContactMessageRequest struct {
+	InstanceID     string `json:"instance_id" binding:"required"`
+	Phone          string `json:"phone"`
+	IsGroup        bool   `json:"is_group"`
+	RecipientJID   string `json:"recipient_jid,omitempty"`
+	ContactName    string `json:"contact_name" binding:"required"`
+	ContactPhone   string `json:"contact_phone" binding:"required"`
+	TimeoutSeconds int    `json:"timeout_seconds,omitempty"`
+}
+
+// SendPollRequest sends a poll message.
+type SendPollRequest struct {
+	InstanceID   string   `json:"instance_id" binding:"required"`
+	Phone        string   `json:"phone"`
+	IsGroup      bool     `json:"is_group"`
+	RecipientJID string   `json:"recipient_jid,omitempty"`
+	Question     string   `json:"question" binding:"required"`
+	Options      []string `json:"options" binding:"required,min=2,max=12"`
+	// SelectableCount is how many options a voter may pick; 0 and 1 both
+	// mean single-select.
+	SelectableCount int `json:"selectable_count,omitempty"`
+	TimeoutSeconds  int `json:"timeout_seconds,omitempty"`
+}
+
+// SendReactionRequest reacts to an existing message.
+type SendReactionRequest struct {
+	InstanceID   string `json:"instance_id" binding:"required"`
+	Phone        string `json:"phone"`
+	IsGroup      bool   `json:"is_group"`
+	RecipientJID string `json:"recipient_jid,omitempty"`
+	MessageID    string `json:"message_id" binding:"required"`
+	// Emoji to react with; empty removes the reaction.
+	Emoji          string `json:"emoji"`
+	TimeoutSeconds int    `json:"timeout_seconds,omitempty"`
+}
+
+// PinMessageRequest pins or unpins a message in a chat.
+type PinMessageRequest struct {
+	InstanceID string `json:"instance_id" binding:"required"`
+	Chat       string `json:"chat" binding:"required"`
+	IsGroup    bool   `json:"is_group"`
+	MessageID  string `json:"message_id" binding:"required"`
+	// DurationSeconds is how long the pin lasts; WhatsApp allows 24h, 7d
+	// and 30d. Ignored when unpinning.
+	DurationSeconds uint32 `json:"duration_seconds"`
+	TimeoutSeconds  int    `json:"timeout_seconds,omitempty"`
+}
+
+// StarMessageRequest stars or unstars a message in a chat. The message
+// must be known to the local store.
+// RevokeMessageRequest deletes a message for everyone via the revoke
+// protocol. SenderJID targets someone else's message in a group where the
+// instance is an admin; empty revokes the instance's own message.
+type RevokeMessageRequest struct {
+	InstanceID   string `json:"instance_id" binding:"required"`
+	Phone        string `json:"phone"`
+	IsGroup      bool   `json:"is_group"`
+	RecipientJID string `json:"recipient_jid,omitempty"`
+	MessageID    string `json:"message_id" binding:"required"`
+	SenderJID    string `json:"sender_jid,omitempty"`
+}
+
+type StarMessageRequest struct {
+	InstanceID string `json:"instance_id" binding:"required"`
+	Chat       string `json:"chat" binding:"required"`
+	MessageID  string `json:"message_id" binding:"required"`
+	Starred    *bool  `json:"starred" binding:"required"`
+}
+
+// CreateTemplateRequest stores a reusable message template; Content may
+// contain {{placeholder}} parameters filled in at send time.
+type CreateTemplateRequest struct {
+	Name    string `json:"name" binding:"required"`
+	Content string `json:"content" binding:"required"`
+}
+
+// UpdateTemplateRequest updates a template; nil fields are left untouched.
+type UpdateTemplateRequest struct {
+	Name    *string `json:"name,omitempty"`
+	Content *string `json:"content,omitempty"`
+}
+
+// SendTemplateMessageRequest renders a stored template with the given
+// parameter values and sends the result as a text message.
+type SendTemplateMessageRequest struct {
+	InstanceID     string            `json:"instance_id" binding:"required"`
+	Phone          string            `json:"phone"`
+	IsGroup        bool              `json:"is_group"`
+	RecipientJID   string            `json:"recipient_jid,omitempty"`
+	TemplateID     string            `json:"template_id" binding:"required"`
+	Params         map[string]string `json:"params,omitempty"`
+	TimeoutSeconds int               `json:"timeout_seconds,omitempty"`
+}
+
+// SendBulkTextRequest sends the same text message to many recipients as
+// a background job.
+type SendBulkTextRequest struct {
+	InstanceID string   `json:"instance_id" binding:"required"`
+	Phones     []string `json:"phones" binding:"required,min=1"`
+	Message    string   `json:"message" binding:"required"`
+	// DelayMs is the pause between recipients; defaults to one second to
+	// stay clear of spam detection.
+	DelayMs        int `json:"delay_ms,omitempty"`
+	TimeoutSeconds int `json:"timeout_seconds,omitempty"`
+}
+
+// ScheduleTextMessageRequest sends a text message at a later time.
+type ScheduleTextMessageRequest struct {
+	InstanceID string `json:"instance_id" binding:"required"`
+	Phone      string `json:"phone" binding:"required"`
+	IsGroup    bool   `json:"is_group"`
+	Message    string `json:"message" binding:"required"`
+	// SendAt is an RFC 3339 timestamp in the future.
+	SendAt         string `json:"send_at" binding:"required"`
+	TimeoutSeconds int    `json:"timeout_seconds,omitempty"`
+}
+
+// RequestPhoneNumberRequest asks a contact to share their phone number.
+// Only direct chats are valid recipients.
+type RequestPhoneNumberRequest struct {
+	InstanceID     string `json:"instance_id" binding:"required"`
+	Phone          string `json:"phone" binding:"required"`
+	TimeoutSeconds int    `json:"timeout_seconds,omitempty"`
+}
+
+// SetPushNameRequest changes the display name other users see.
+type SetPushNameRequest struct {
+	PushName string `json:"push_name" binding:"required"`
+}
+
+// MarkReadRequest marks one or more messages in a chat as read.
+type MarkReadRequest struct {
+	InstanceID string   `json:"instance_id" binding:"required"`
+	Phone      string   `json:"phone" binding:"required"`
+	IsGroup    bool     `json:"is_group"`
+	MessageIDs []string `json:"message_ids" binding:"required"`
+	SenderJID  string   `json:"sender_jid,omitempty"`
+}
+
+// ChatPresenceRequest sends a typing/recording indicator to a chat.
+type ChatPresenceRequest struct {
+	InstanceID string `json:"instance_id" binding:"required"`
+	Phone      string `json:"phone" binding:"required"`
+	IsGroup    bool   `json:"is_group"`
+	// State is "composing" or "paused"; Media "audio" marks recording.
+	State string `json:"state" binding:"required"`
+	Media string `json:"media,omitempty"`
+}
+
+// DownloadMediaRequest downloads inbound media using the encrypted-media
+// bundle from a received message webhook.
+type DownloadMediaRequest struct {
+	InstanceID    string `json:"instance_id" binding:"required"`
+	MediaURL      string `json:"media_url"`
+	DirectPath    string `json:"direct_path"`
+	MediaKey      []byte `json:"media_key" binding:"required"`
+	FileEncSHA256 []byte `json:"file_enc_sha256" binding:"required"`
+	FileSHA256    []byte `json:"file_sha256" binding:"required"`
+	FileLength    uint64 `json:"file_length"`
+	Mimetype      string `json:"mimetype" binding:"required"`
+	// Type is the media kind: image, video, audio or document.
+	Type string `json:"type" binding:"required"`
+}
+
+// CheckContactsRequest checks which phone numbers are on WhatsApp.
+// CheckNumberRequest asks whether a phone number is registered on
+// WhatsApp before trying to pair it. instance_id picks the instance the
+// lookup goes through; empty borrows any connected one.
+type CheckNumberRequest struct {
+	Phone      string `json:"phone" binding:"required"`
+	InstanceID string `json:"instance_id"`
+}
+
+type CheckContactsRequest struct {
+	InstanceID string   `json:"instance_id" binding:"required"`
+	Phones     []string `json:"phones" binding:"required"`
+}
+
+// CreateGroupRequest creates a new group. The optional settings are
+// applied right after creation as separate WhatsApp calls, each reported
+// individually in the response.
+type CreateGroupRequest struct {
+	InstanceID   string   `json:"instance_id" binding:"required"`
+	Name         string   `json:"name" binding:"required"`
+	Participants []string `json:"participants" binding:"required"`
+	// AvatarBase64 optionally sets the group photo after creation.
+	AvatarBase64 string `json:"avatar_base64,omitempty"`
+	// Announce restricts sending messages to admins.
+	Announce *bool `json:"announce,omitempty"`
+	// Locked restricts editing group info to admins.
+	Locked *bool `json:"locked,omitempty"`
+	// EphemeralSeconds sets the disappearing-message timer (0, 24h, 7d
+	// or 90d in seconds).
+	EphemeralSeconds *uint32 `json:"ephemeral_seconds,omitempty"`
+}
+
+// UpdateGroupRequest updates group metadata; nil fields are left untouched.
+type UpdateGroupRequest struct {
+	Name         *string `json:"name,omitempty"`
+	Description  *string `json:"description,omitempty"`
+	AvatarBase64 *string `json:"avatar_base64,omitempty"`
+}
+
+// ParticipantsRequest adds/removes/promotes/demotes group participants.
+type ParticipantsRequest struct {
+	Participants []string `json:"participants" binding:"required"`
+}
+
+// SendStatusRequest publishes a status (story) update.
+type SendStatusRequest struct {
+	InstanceID string `json:"instance_id" binding:"required"`
+	// Type is "text", "image" or "video".
+	Type           string `json:"type" binding:"required"`
+	Content        string `json:"content,omitempty"`
+	MediaBase64    string `json:"media_base64,omitempty"`
+	Mimetype       string `json:"mimetype,omitempty"`
+	TimeoutSeconds int    `json:"timeout_seconds,omitempty"`
+}
+
+// ConfigureWebhookRequest creates or updates a webhook.
+// WebhookExportItem is the portable shape of one webhook used by the
+// export and import endpoints. IDs are intentionally absent so imports
+// always regenerate them.
+type WebhookExportItem struct {
+	URL    string `json:"url"`
+	Secret string `json:"secret,omitempty"`
+	// HasSecret marks an export whose secret was redacted, so importers
+	// know one must be supplied again.
+	HasSecret          bool                `json:"has_secret,omitempty"`
+	Events             []string            `json:"events"`
+	Active             *bool               `json:"active,omitempty"`
+	InsecureTLS        *bool               `json:"insecure_tls,omitempty"`
+	CACertPath         *string             `json:"ca_cert_path,omitempty"`
+	Transform          *string             `json:"transform,omitempty"`
+	SignatureAlgorithm *string             `json:"signature_algorithm,omitempty"`
+	Filters            []WebhookFilterSpec `json:"filters,omitempty"`
+}
+
+// WebhookFilterSpec is a webhook filter without its IDs, as carried in
+// exports and imports.
+type WebhookFilterSpec struct {
+	Field    string `json:"field"`
+	Operator string `json:"operator"`
+	Value    string `json:"value"`
+}
+
+// ImportWebhooksRequest recreates exported webhooks on an instance.
+// dry_run validates and previews without writing anything.
+type ImportWebhooksRequest struct {
+	Webhooks []WebhookExportItem `json:"webhooks" binding:"required,min=1"`
+	DryRun   bool                `json:"dry_run"`
+}
+
+type ConfigureWebhookRequest struct {
+	URL    string   `json:"url" binding:"required"`
+	Secret string   `json:"secret,omitempty"`
+	Events []string `json:"events"`
+	Active *bool    `json:"active,omitempty"`
+	// InsecureTLS disables certificate verification for this webhook;
+	// CACertPath trusts a PEM bundle instead of the system roots.
+	InsecureTLS *bool   `json:"insecure_tls,omitempty"`
+	CACertPath  *string `json:"ca_cert_path,omitempty"`
+	// Transform reshapes the delivery body with a Go text/template
+	// (rendered with .Event and .Data); empty string removes it.
+	Transform *string `json:"transform,omitempty"`
+	// SignatureAlgorithm selects the delivery HMAC: sha256 or sha512.
+	SignatureAlgorithm *string `json:"signature_algorithm,omitempty"`
+	// PresenceThrottleSeconds bounds contact.presence deliveries to one
+	// per contact per window. Zero uses the 10-second default; negative
+	// disables throttling.
+	PresenceThrottleSeconds *int `json:"presence_throttle_seconds,omitempty"`
+}
+
+// VerifyWebhookSignatureRequest checks a received webhook signature
+// against the stored secret of a webhook.
+type VerifyWebhookSignatureRequest struct {
+	WebhookID string `json:"webhook_id" binding:"required"`
+	// Timestamp is the X-Webhook-Timestamp header of the delivery.
+	Timestamp string `json:"timestamp" binding:"required"`
+	// Signature is the X-Webhook-Signature header, with or without the
+	// "sha256=" prefix.
+	Signature string `json:"signature" binding:"required"`
+	// Body is the raw request body exactly as received.
+	Body string `json:"body" binding:"required"`
+}
+
+// UpdateCallSettingsRequest replaces the call settings for an instance.
+type UpdateCallSettingsRequest struct {
+	AutoReject     *bool             `json:"auto_reject,omitempty"`
+	Whitelist      []string          `json:"whitelist,omitempty"`
+	CustomMessages map[string]string `json:"custom_messages,omitempty"`
+	// RejectMessageCooldown in seconds; 0 disables the cooldown.
+	RejectMessageCooldown *int    `json:"reject_message_cooldown,omitempty"`
+	RejectAudioCalls      *bool   `json:"reject_audio_calls,omitempty"`
+	RejectVideoCalls      *bool   `json:"reject_video_calls,omitempty"`
+	RejectScheduleStart   *string `json:"reject_schedule_start,omitempty"`
+	RejectScheduleEnd     *string `json:"reject_schedule_end,omitempty"`
+}
+
+// MessageHistoryRequest filters stored messages for a chat.
+type MessageHistoryRequest struct {
+	Phone    string `form:"phone"`
+	IsGroup  bool   `form:"is_group"`
+	Limit    int    `form:"limit"`
+	Offset   int    `form:"offset"`
+	FromTime string `form:"from_time"`
+	ToTime   string `form:"to_time"`
+}
@@ -0,0 +1,59 @@
+package models
+
+import "time"
+
+// SentMessageInfo describes a message in API responses, both right after
+// sending and when returned from history queries.
+type SentMessageInfo struct {
+	MessageID string    `json:"message_id"`
+	ChatJID   string    `json:"chat_jid"`
+	SenderJID string    `json:"sender_jid,omitempty"`
+	FromMe    bool      `json:"from_me"`
+	Type      string    `json:"type"`
+	Content   string    `json:"content,omitempty"`
+	Status    string    `json:"status,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// MessageHistoryResponse is the paginated result of a history query.
+type MessageHistoryResponse struct {
+	Messages []SentMessageInfo `json:"messages"`
+	Total    int64             `json:"total"`
+	Limit    int               `json:"limit"`
+	Offset   int               `json:"offset"`
+}
+
+// ContactInfo is the aggregated profile information for one contact.
+type ContactInfo struct {
+	JID          string `json:"jid"`
+	Phone        string `json:"phone"`
+	Name         string `json:"name,omitempty"`
+	PushName     string `json:"push_name,omitempty"`
+	AvatarURL    string `json:"avatar_url,omitempty"`
+	IsBusiness   bool   `json:"is_business"`
+	IsOnWhatsApp bool   `json:"is_on_whatsapp"`
+	Status       string `json:"status,omitempty"`
+}
+
+// GroupParticipantInfo describes one participant in a group response.
+type GroupParticipantInfo struct {
+	JID          string `json:"jid"`
+	LID          string `json:"lid,omitempty"`
+	Phone        string `json:"phone"`
+	IsAdmin      bool   `json:"is_admin"`
+	IsSuperAdmin bool   `json:"is_super_admin"`
+}
+
+// GroupInfo is the detailed group structure returned by group endpoints.
+type GroupInfo struct {
+	JID              string                 `json:"jid"`
+	Name             string                 `json:"name"`
+	Topic            string                 `json:"topic,omitempty"`
+	OwnerJID         string                 `json:"owner_jid,omitempty"`
+	CreatedAt        time.Time              `json:"created_at"`
+	IsAnnounce       bool                   `json:"is_announce"`
+	IsLocked         bool                   `json:"is_locked"`
+	IsEphemeral      bool                   `json:"is_ephemeral"`
+	ParticipantCount int                    `json:"participant_count"`
+	Participants     []GroupParticipantInfo `json:"participants,omitempty"`
+}
@@ -0,0 +1,216 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/Layoxd/whatsapiGo/src/controllers"
+	"github.com/Layoxd/whatsapiGo/src/middleware"
+	"github.com/Layoxd/whatsapiGo/src/services"
+)
+
+// Setup wires all controllers onto a gin engine. The instance controller
+// is returned alongside the engine so main can restore persisted
+// instances before serving.
+func Setup(webhookService *services.WebhookService) (*gin.Engine, *controllers.InstanceController) {
+	instanceController := controllers.NewInstanceController(webhookService)
+	messageController := controllers.NewMessageController(instanceController)
+	contactController := controllers.NewContactController(instanceController)
+	groupController := controllers.NewGroupController(instanceController)
+	statusController := controllers.NewStatusController(instanceController)
+	webhookController := controllers.NewWebhookController(webhookService)
+	callController := controllers.NewCallController(instanceController, webhookService)
+	chatController := controllers.NewChatController(instanceController)
+	labelController := controllers.NewLabelController(instanceController)
+	templateController := controllers.NewTemplateController(instanceController)
+	jobController := controllers.NewJobController(instanceController, messageController)
+	logController := controllers.NewLogController()
+	adminController := controllers.NewAdminController()
+
+	instanceController.SetCallController(callController)
+
+	r := gin.Default()
+	r.GET("/health", func(c *gin.Context) {
+		c.JSON(200, gin.H{"status": "ok"})
+	})
+
+	api := r.Group("/", middleware.APIKeyAuth())
+
+	instances := api.Group("/instances")
+	{
+		instances.POST("", instanceController.CreateInstance)
+		instances.POST("/batch", instanceController.BatchCreateInstances)
+		instances.POST("/check-number", instanceController.CheckNumber)
+		instances.GET("", instanceController.GetInstances)
+		instances.GET("/:id", instanceController.GetInstance)
+		instances.PATCH("/:id", instanceController.UpdateInstance)
+		instances.DELETE("/:id", instanceController.DeleteInstance)
+		instances.GET("/:id/qr", instanceController.GetQRCode)
+		instances.POST("/:id/connect", instanceController.ConnectInstance)
+		instances.POST("/:id/disconnect", instanceController.DisconnectInstance)
+		instances.POST("/:id/logout", instanceController.LogoutInstance)
+		instances.POST("/:id/reset", instanceController.ResetInstanceSession)
+		instances.GET("/:id/sync-status", instanceController.GetSyncStatus)
+		instances.GET("/:id/sync-progress", instanceController.GetSyncProgress)
+		instances.GET("/:id/me", instanceController.GetMe)
+		instances.PUT("/:id/pushname", instanceController.SetPushName)
+		instances.PUT("/:id/disappearing-default", instanceController.SetDisappearingDefault)
+		instances.GET("/:id/read-receipts", instanceController.GetReadReceipts)
+		instances.PUT("/:id/read-receipts", instanceController.SetReadReceipts)
+		instances.GET("/:id/stats", instanceController.GetInstanceStats)
+		instances.GET("/:id/event-counts", instanceController.GetEventCounts)
+		instances.GET("/:id/export", instanceController.ExportInstance)
+		instances.GET("/:id/events/export", instanceController.ExportEventsNDJSON)
+		instances.GET("/:id/device-limit", instanceController.GetDeviceLimit)
+	}
+
+	messages := api.Group("/messages")
+	{
+		messages.POST("/text", messageController.SendTextMessage)
+		messages.POST("/template", messageController.SendTemplateMessage)
+		messages.POST("/bulk", jobController.SendBulkText)
+		messages.POST("/schedule", jobController.ScheduleText)
+		messages.POST("/media", messageController.SendMediaMessage)
+		messages.POST("/album", messageController.SendAlbum)
+		messages.POST("/location", messageController.SendLocationMessage)
+		messages.POST("/live-location", messageController.SendLiveLocationMessage)
+		messages.POST("/contact", messageController.SendContactMessage)
+		messages.POST("/request-phone", messageController.SendRequestPhoneNumber)
+		messages.POST("/poll", messageController.SendPoll)
+		messages.POST("/reaction", messageController.SendReaction)
+		messages.POST("/revoke", messageController.RevokeMessage)
+		messages.POST("/star", messageController.StarMessage)
+		messages.POST("/pin", messageController.PinMessage)
+		messages.POST("/unpin", messageController.UnpinMessage)
+		messages.POST("/mark-read", messageController.MarkRead)
+		messages.POST("/presence", messageController.SendChatPresence)
+		messages.GET("/presence/:instanceId/:chat", messageController.GetChatPresenceState)
+		messages.DELETE("/presence/:instanceId/:chat", messageController.ClearChatPresenceState)
+		messages.POST("/download", messageController.DownloadMedia)
+		messages.GET("/:instanceId/history", messageController.GetMessageHistory)
+		messages.GET("/:instanceId/:messageId", messageController.GetMessage)
+		messages.GET("/:instanceId/:messageId/read-by", messageController.GetMessageReadBy)
+		messages.GET("/:instanceId/:messageId/group-status", messageController.GetGroupDeliveryStatus)
+		messages.GET("/:instanceId/:messageId/poll-results", messageController.GetPollResults)
+	}
+
+	contacts := api.Group("/contacts")
+	{
+		contacts.POST("/check", contactController.CheckContacts)
+		contacts.POST("/:instanceId/batch-info", contactController.BatchContactInfo)
+		contacts.POST("/:instanceId/avatars", contactController.BatchAvatars)
+		contacts.GET("/:instanceId/:jid", middleware.ValidateJID("jid"), contactController.GetContactInfo)
+		contacts.GET("/:instanceId/:jid/identity", middleware.ValidateJID("jid"), contactController.GetContactIdentity)
+		contacts.GET("/:instanceId/:jid/business-hours", middleware.ValidateJID("jid"), contactController.GetBusinessHours)
+	}
+
+	groups := api.Group("/groups")
+	{
+		groups.POST("", groupController.CreateGroup)
+		groups.POST("/join", groupController.JoinGroup)
+		groups.GET("/:instanceId", groupController.GetGroups)
+		groups.GET("/:instanceId/:groupId", middleware.ValidateJID("groupId"), groupController.GetGroup)
+		groups.PUT("/:instanceId/:groupId", middleware.ValidateJID("groupId"), groupController.UpdateGroup)
+		groups.POST("/:instanceId/:groupId/leave", middleware.ValidateJID("groupId"), groupController.LeaveGroup)
+		groups.GET("/:instanceId/:groupId/changelog", middleware.ValidateJID("groupId"), groupController.GetGroupChangelog)
+		groups.GET("/:instanceId/:groupId/my-role", middleware.ValidateJID("groupId"), groupController.GetMyRole)
+		groups.GET("/:instanceId/:groupId/invite-link", middleware.ValidateJID("groupId"), groupController.GetInviteLink)
+		groups.GET("/:instanceId/:groupId/invite-link/info", middleware.ValidateJID("groupId"), groupController.GetInviteLinkInfo)
+		groups.POST("/:instanceId/:groupId/participants/add", middleware.ValidateJID("groupId"), groupController.AddParticipants)
+		groups.POST("/:instanceId/:groupId/participants/remove", middleware.ValidateJID("groupId"), groupController.RemoveParticipants)
+		groups.POST("/:instanceId/:groupId/participants/promote", middleware.ValidateJID("groupId"), groupController.PromoteParticipants)
+		groups.POST("/:instanceId/:groupId/participants/demote", middleware.ValidateJID("groupId"), groupController.DemoteParticipants)
+	}
+
+	api.GET("/chats/:instanceId", chatController.GetChats)
+	api.GET("/chats/:instanceId/:chat/starred", chatController.GetStarredMessages)
+
+	labels := api.Group("/labels")
+	{
+		labels.GET("/:instanceId", labelController.GetLabels)
+		labels.POST("/:instanceId", labelController.CreateLabel)
+		labels.POST("/:instanceId/:labelId/assign", labelController.AssignLabel)
+		labels.POST("/:instanceId/:labelId/unassign", labelController.UnassignLabel)
+	}
+
+	jobs := api.Group("/jobs")
+	{
+		jobs.GET("/:instanceId", jobController.GetJobs)
+		jobs.DELETE("/:instanceId/:jobId", jobController.CancelJob)
+	}
+
+	templates := api.Group("/templates")
+	{
+		templates.GET("/:instanceId", templateController.GetTemplates)
+		templates.POST("/:instanceId", templateController.CreateTemplate)
+		templates.PUT("/:instanceId/:templateId", templateController.UpdateTemplate)
+		templates.DELETE("/:instanceId/:templateId", templateController.DeleteTemplate)
+	}
+
+	status := api.Group("/status")
+	{
+		status.POST("", statusController.SendStatus)
+		status.GET("/:instanceId", statusController.GetContactStatuses)
+		status.GET("/:instanceId/:statusId/media", statusController.GetStatusMedia)
+		status.POST("/:instanceId/:statusId/react", statusController.ReactToStatus)
+		status.POST("/:instanceId/:statusId/forward", statusController.ForwardStatus)
+		status.DELETE("/:instanceId/:statusId", statusController.DeleteStatus)
+	}
+
+	webhooks := api.Group("/webhooks")
+	{
+		webhooks.POST("/:instanceId", webhookController.ConfigureWebhook)
+		webhooks.GET("/:instanceId", webhookController.GetWebhooks)
+		webhooks.PUT("/:instanceId/:webhookId", webhookController.UpdateWebhook)
+		webhooks.DELETE("/:instanceId/:webhookId", webhookController.DeleteWebhook)
+		webhooks.POST("/:instanceId/:webhookId/test", webhookController.TestWebhook)
+		webhooks.POST("/:instanceId/verify", webhookController.VerifyWebhookSignature)
+		webhooks.GET("/:instanceId/export", webhookController.ExportWebhooks)
+		webhooks.POST("/:instanceId/import", webhookController.ImportWebhooks)
+		webhooks.POST("/:instanceId/simulate", webhookController.SimulateEvent)
+		webhooks.POST("/:instanceId/pause-all", webhookController.PauseAllDeliveries)
+		webhooks.POST("/:instanceId/resume-all", webhookController.ResumeAllDeliveries)
+		webhooks.POST("/:instanceId/replay", webhookController.ReplayStoredEvents)
+		webhooks.GET("/:instanceId/:webhookId/effective-subscription", webhookController.GetEffectiveSubscription)
+		webhooks.POST("/:instanceId/:webhookId/mute", webhookController.MuteWebhookEvents)
+		webhooks.POST("/:instanceId/:webhookId/unmute", webhookController.UnmuteWebhookEvents)
+		webhooks.GET("/:instanceId/:webhookId/logs", webhookController.GetWebhookLogs)
+		webhooks.POST("/:instanceId/logs/:logId/retry", webhookController.RetryEvent)
+		webhooks.POST("/:instanceId/:webhookId/retry-failed", webhookController.RetryFailedEvents)
+		webhooks.POST("/:instanceId/:webhookId/filters", webhookController.AddWebhookFilter)
+		webhooks.GET("/:instanceId/:webhookId/filters", webhookController.GetWebhookFilters)
+		webhooks.DELETE("/:instanceId/:webhookId/filters/:filterId", webhookController.DeleteWebhookFilter)
+	}
+
+	calls := api.Group("/calls")
+	{
+		calls.GET("/:instanceId/settings", callController.GetCallSettings)
+		calls.PUT("/:instanceId/settings", callController.UpdateCallSettings)
+		calls.POST("/:instanceId/whitelist/add", callController.AddToWhitelist)
+		calls.POST("/:instanceId/whitelist/remove", callController.RemoveFromWhitelist)
+		calls.GET("/:instanceId/logs", callController.GetCallLogs)
+		calls.GET("/:instanceId/stats", callController.GetCallStats)
+	}
+
+	admin := api.Group("/admin")
+	{
+		admin.GET("/instances/:id/device", instanceController.GetDeviceDebugInfo)
+		admin.GET("/instances/consistency", instanceController.GetInstancesConsistency)
+		admin.GET("/instances/duplicates", instanceController.GetInstanceDuplicates)
+		admin.GET("/resources", instanceController.GetResourceStats)
+		admin.POST("/purge-deleted", adminController.PurgeSoftDeleted)
+		admin.POST("/instances/consistency/repair", instanceController.RepairInstancesConsistency)
+		admin.GET("/migrations", adminController.GetMigrationsStatus)
+
+		keys := admin.Group("/keys", middleware.MasterKeyAuth())
+		{
+			keys.GET("", adminController.ListAPIKeys)
+			keys.POST("", adminController.CreateAPIKey)
+			keys.DELETE("/:keyId", adminController.RevokeAPIKey)
+		}
+	}
+
+	api.GET("/logs", logController.GetLogs)
+	api.GET("/capabilities", webhookController.GetCapabilities)
+
+	return r, instanceController
+}
@@ -0,0 +1,82 @@
+package services
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"google.golang.org/protobuf/proto"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/Layoxd/whatsapiGo/src/database"
+	"github.com/Layoxd/whatsapiGo/src/models"
+)
+
+// TestEventHandlerWritesWebhookLog runs a fake message event through the
+// handler that instance event workers register and checks the full path:
+// the event is logged, the configured webhook is called and the delivery
+// attempt lands in webhook_logs.
+func TestEventHandlerWritesWebhookLog(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("opening in-memory database: %v", err)
+	}
+	if err := db.AutoMigrate(database.MigratedModels()...); err != nil {
+		t.Fatalf("migrating: %v", err)
+	}
+
+	received := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- struct{}{}
+	}))
+	defer server.Close()
+
+	db.Create(&models.Webhook{
+		ID:         "wh-1",
+		InstanceID: "inst-1",
+		URL:        server.URL,
+		Events:     `["message.received"]`,
+		Active:     true,
+	})
+
+	ws := NewWebhookService(db)
+	handler := ws.EventHandler("inst-1")
+	handler(buttonResponseEvent(&waE2E.Message{Conversation: proto.String("hello")}))
+
+	select {
+	case <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook endpoint was never called")
+	}
+
+	var eventCount int64
+	db.Model(&models.EventLog{}).
+		Where("instance_id = ? AND event_type = ?", "inst-1", "message.received").
+		Count(&eventCount)
+	if eventCount != 1 {
+		t.Errorf("event log rows = %d, want 1", eventCount)
+	}
+
+	// The delivery log is written after the HTTP call returns, so give
+	// the delivery goroutine a moment to finish.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		var logCount int64
+		db.Model(&models.WebhookLog{}).
+			Where("webhook_id = ? AND event_type = ?", "wh-1", "message.received").
+			Count(&logCount)
+		if logCount == 1 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("webhook log rows = %d, want 1", logCount)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
@@ -0,0 +1,920 @@
+package services
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"text/template"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	"gorm.io/gorm"
+
+	"github.com/Layoxd/whatsapiGo/src/config"
+	"github.com/Layoxd/whatsapiGo/src/models"
+	"github.com/Layoxd/whatsapiGo/src/utils"
+)
+
+// WebhookService turns whatsmeow events into webhook deliveries. It
+// classifies events into the public event catalog, records them in the
+// event log and posts signed payloads to every matching webhook.
+type WebhookService struct {
+	db     *gorm.DB
+	client *http.Client
+
+	// pausedMu guards paused, which maps instance IDs to the moment their
+	// deliveries were paused. Paused events are still logged.
+	pausedMu sync.RWMutex
+	paused   map[string]time.Time
+
+	// slotsMu guards slots, the per-instance semaphores bounding
+	// concurrent deliveries.
+	slotsMu sync.Mutex
+	slots   map[string]*deliverySlots
+
+	// storedMediaMu guards storedMedia, which holds the paths of
+	// auto-downloaded files keyed by instance and message ID until the
+	// message.received payload is built.
+	storedMediaMu sync.Mutex
+	storedMedia   map[string]string
+
+	// presenceMu guards presenceSent, which tracks when each webhook
+	// last received a presence event per contact, for throttling.
+	presenceMu   sync.Mutex
+	presenceSent map[string]time.Time
+
+	// pollVotesMu guards pollVotes, which holds decrypted vote options
+	// keyed by instance and update-message ID until the poll.vote
+	// payload is built. Decryption needs the whatsmeow client, so the
+	// event worker decrypts and hands the result over here.
+	pollVotesMu sync.Mutex
+	pollVotes   map[string][]string
+}
+
+// defaultPresenceThrottle is how long a webhook waits between presence
+// events for the same contact when no per-webhook value is configured.
+const defaultPresenceThrottle = 10 * time.Second
+
+// deliverySlots is a semaphore sized to an instance's effective delivery
+// concurrency. It is rebuilt when the limit changes.
+type deliverySlots struct {
+	limit int
+	ch    chan struct{}
+}
+
+// NewWebhookService creates a webhook service backed by the given database.
+func NewWebhookService(db *gorm.DB) *WebhookService {
+	return &WebhookService{
+		db: db,
+		client: &http.Client{
+			Timeout: time.Duration(config.Get().WebhookTimeoutSeconds) * time.Second,
+		},
+		paused:       make(map[string]time.Time),
+		slots:        make(map[string]*deliverySlots),
+		storedMedia:  make(map[string]string),
+		presenceSent: make(map[string]time.Time),
+		pollVotes:    make(map[string][]string),
+	}
+}
+
+// EventCatalog lists every event type the service can emit. The
+// capabilities endpoint exposes it so integrators know what to subscribe to.
+var EventCatalog = []string{
+	"message.received",
+	"message.ack",
+	"message.reaction",
+	"message.undecryptable",
+	"message.button_response",
+	"poll.vote",
+	"contact.presence",
+	"contact.identity_change",
+	"group.update",
+	"group.participants",
+	"call.received",
+	"call.rejected",
+	"instance.connected",
+	"instance.disconnected",
+	"instance.logged_out",
+	"instance.qr",
+	"instance.error",
+	"instance.duplicate",
+}
+
+// GlobalInstanceID is the pseudo instance ID for webhooks that receive
+// events from every instance. Payloads always carry the originating
+// instance_id, so global consumers can tell events apart.
+const GlobalInstanceID = "*"
+
+// KnownEventType reports whether eventType is part of the event catalog.
+func KnownEventType(eventType string) bool {
+	for _, ev := range EventCatalog {
+		if ev == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// EventHandler returns a whatsmeow event handler bound to an instance,
+// suitable for Client.AddEventHandler.
+func (ws *WebhookService) EventHandler(instanceID string) func(evt interface{}) {
+	return func(evt interface{}) {
+		ws.processEvent(instanceID, evt)
+	}
+}
+
+// processEvent classifies the event, records it in the event log and
+// delivers it to matching webhooks.
+func (ws *WebhookService) processEvent(instanceID string, evt interface{}) {
+	eventType, data := ws.classifyEvent(instanceID, evt)
+	if eventType == "" {
+		return
+	}
+	payload, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("webhook: marshaling %s event for instance %s: %v", eventType, instanceID, err)
+		return
+	}
+	ws.db.Create(&models.EventLog{
+		InstanceID: instanceID,
+		EventType:  eventType,
+		Payload:    string(payload),
+	})
+	if ws.IsPaused(instanceID) {
+		return
+	}
+	ws.Dispatch(instanceID, eventType, data)
+}
+
+// PauseDeliveries stops webhook deliveries for an instance. Events keep
+// being logged so they can be replayed on resume.
+func (ws *WebhookService) PauseDeliveries(instanceID string) {
+	ws.pausedMu.Lock()
+	defer ws.pausedMu.Unlock()
+	if _, already := ws.paused[instanceID]; !already {
+		ws.paused[instanceID] = time.Now()
+	}
+}
+
+// ResumeDeliveries re-enables deliveries for an instance. With replay,
+// the events logged while paused are dispatched before returning; the
+// count of replayed events is reported.
+func (ws *WebhookService) ResumeDeliveries(instanceID string, replay bool) int {
+	ws.pausedMu.Lock()
+	pausedAt, wasPaused := ws.paused[instanceID]
+	delete(ws.paused, instanceID)
+	ws.pausedMu.Unlock()
+	if !wasPaused || !replay {
+		return 0
+	}
+
+	var missed []models.EventLog
+	if err := ws.db.
+		Where("instance_id = ? AND created_at >= ?", instanceID, pausedAt).
+		Order("created_at ASC").
+		Find(&missed).Error; err != nil {
+		log.Printf("webhook: loading paused events for instance %s: %v", instanceID, err)
+		return 0
+	}
+	replayed := 0
+	for _, entry := range missed {
+		var data map[string]interface{}
+		if err := json.Unmarshal([]byte(entry.Payload), &data); err != nil {
+			continue
+		}
+		ws.Dispatch(instanceID, entry.EventType, data)
+		replayed++
+	}
+	return replayed
+}
+
+// ReplayEvents re-dispatches logged events of an instance in a time range
+// to the active webhooks, optionally limited to certain event types. It is
+// the recovery path after a downstream outage: unlike retry, it covers
+// events that never produced a delivery attempt. The count of re-emitted
+// events is reported.
+func (ws *WebhookService) ReplayEvents(instanceID string, from, to time.Time, eventTypes []string) (int, error) {
+	query := ws.db.
+		Where("instance_id = ? AND created_at >= ? AND created_at <= ?", instanceID, from, to)
+	if len(eventTypes) > 0 {
+		query = query.Where("event_type IN ?", eventTypes)
+	}
+	var entries []models.EventLog
+	if err := query.Order("created_at ASC").Find(&entries).Error; err != nil {
+		return 0, err
+	}
+	replayed := 0
+	for _, entry := range entries {
+		var data map[string]interface{}
+		if err := json.Unmarshal([]byte(entry.Payload), &data); err != nil {
+			continue
+		}
+		ws.Dispatch(instanceID, entry.EventType, data)
+		replayed++
+	}
+	return replayed, nil
+}
+
+// IsPaused reports whether deliveries for an instance are paused.
+func (ws *WebhookService) IsPaused(instanceID string) bool {
+	ws.pausedMu.RLock()
+	defer ws.pausedMu.RUnlock()
+	_, paused := ws.paused[instanceID]
+	return paused
+}
+
+// SimulateEvent builds a payload for the given event type from caller
+// supplied fields and runs it through the same logging and dispatch path
+// as a real event, so webhook filters and subscriptions apply. The payload
+// is marked simulated so consumers can tell it apart from real traffic.
+func (ws *WebhookService) SimulateEvent(instanceID, eventType string, data map[string]interface{}) map[string]interface{} {
+	payload := make(map[string]interface{}, len(data)+3)
+	for k, v := range data {
+		payload[k] = v
+	}
+	payload["instance_id"] = instanceID
+	if _, ok := payload["timestamp"]; !ok {
+		payload["timestamp"] = time.Now()
+	}
+	payload["simulated"] = true
+
+	if body, err := json.Marshal(payload); err == nil {
+		ws.db.Create(&models.EventLog{
+			InstanceID: instanceID,
+			EventType:  eventType,
+			Payload:    string(body),
+		})
+	}
+	if !ws.IsPaused(instanceID) {
+		ws.Dispatch(instanceID, eventType, payload)
+	}
+	return payload
+}
+
+// classifyEvent maps a whatsmeow event to a public event type and payload.
+// An empty event type means the event is not exposed through webhooks.
+func (ws *WebhookService) classifyEvent(instanceID string, evt interface{}) (string, map[string]interface{}) {
+	switch e := evt.(type) {
+	case *events.Message:
+		if e.Message.GetReactionMessage() != nil {
+			return "message.reaction", map[string]interface{}{
+				"instance_id": instanceID,
+				"message_id":  e.Message.GetReactionMessage().GetKey().GetID(),
+				"chat":        e.Info.Chat.String(),
+				"sender":      e.Info.Sender.String(),
+				"emoji":       e.Message.GetReactionMessage().GetText(),
+				"timestamp":   e.Info.Timestamp,
+			}
+		}
+		if data := buttonResponseData(instanceID, e); data != nil {
+			return "message.button_response", data
+		}
+		if e.Message.GetPollUpdateMessage() != nil {
+			return "poll.vote", ws.pollVoteData(instanceID, e)
+		}
+		return "message.received", ws.extractMessageData(instanceID, e)
+	case *events.Receipt:
+		return "message.ack", map[string]interface{}{
+			"instance_id": instanceID,
+			"chat":        e.Chat.String(),
+			"sender":      e.Sender.String(),
+			"message_ids": e.MessageIDs,
+			"type":        receiptTypeName(e),
+			"timestamp":   e.Timestamp,
+		}
+	case *events.Presence:
+		return "contact.presence", map[string]interface{}{
+			"instance_id": instanceID,
+			"from":        e.From.String(),
+			"unavailable": e.Unavailable,
+			"last_seen":   e.LastSeen,
+		}
+	case *events.IdentityChange:
+		return "contact.identity_change", map[string]interface{}{
+			"instance_id": instanceID,
+			"jid":         e.JID.ToNonAD().String(),
+			"implicit":    e.Implicit,
+			"timestamp":   e.Timestamp,
+		}
+	case *events.UndecryptableMessage:
+		// whatsmeow automatically asks the sender to retry unless the
+		// ciphertext was never sent to this device at all.
+		return "message.undecryptable", map[string]interface{}{
+			"instance_id":        instanceID,
+			"message_id":         e.Info.ID,
+			"chat":               e.Info.Chat.String(),
+			"sender":             e.Info.Sender.String(),
+			"is_unavailable":     e.IsUnavailable,
+			"fail_mode":          string(e.DecryptFailMode),
+			"retry_receipt_sent": !e.IsUnavailable,
+			"timestamp":          e.Info.Timestamp,
+		}
+	case *events.GroupInfo:
+		if data := groupParticipantsData(instanceID, e); data != nil {
+			return "group.participants", data
+		}
+		data := map[string]interface{}{
+			"instance_id": instanceID,
+			"group":       e.JID.String(),
+			"timestamp":   e.Timestamp,
+		}
+		if e.Sender != nil {
+			data["actor"] = e.Sender.ToNonAD().String()
+		}
+		if e.Name != nil {
+			data["name"] = e.Name.Name
+		}
+		if e.Topic != nil {
+			data["topic"] = e.Topic.Topic
+		}
+		if e.Locked != nil {
+			data["locked"] = e.Locked.IsLocked
+		}
+		if e.Announce != nil {
+			data["announce"] = e.Announce.IsAnnounce
+		}
+		return "group.update", data
+	case *events.JoinedGroup:
+		return "group.update", map[string]interface{}{
+			"instance_id": instanceID,
+			"group":       e.JID.String(),
+			"action":      "joined",
+			"name":        e.GroupName.Name,
+			"timestamp":   time.Now(),
+		}
+	case *events.Connected:
+		return "instance.connected", map[string]interface{}{"instance_id": instanceID}
+	case *events.Disconnected:
+		return "instance.disconnected", map[string]interface{}{"instance_id": instanceID}
+	case *events.LoggedOut:
+		return "instance.logged_out", map[string]interface{}{
+			"instance_id": instanceID,
+			"reason":      e.Reason.String(),
+		}
+	case *events.StreamError:
+		return "instance.error", map[string]interface{}{
+			"instance_id": instanceID,
+			"error_type":  "stream_error",
+			"code":        e.Code,
+		}
+	case *events.ClientOutdated:
+		return "instance.error", map[string]interface{}{
+			"instance_id": instanceID,
+			"error_type":  "client_outdated",
+		}
+	case *events.TemporaryBan:
+		return "instance.error", map[string]interface{}{
+			"instance_id": instanceID,
+			"error_type":  "temporary_ban",
+			"code":        int(e.Code),
+			"reason":      e.Code.String(),
+			// How long to wait before reconnecting; zero means unknown.
+			"retry_after_seconds": int(e.Expire.Seconds()),
+		}
+	case *events.ConnectFailure:
+		return "instance.error", map[string]interface{}{
+			"instance_id": instanceID,
+			"error_type":  "connect_failure",
+			"code":        int(e.Reason),
+			"reason":      e.Reason.String(),
+			"message":     e.Message,
+		}
+	default:
+		return "", nil
+	}
+}
+
+// groupParticipantsData builds the group.participants payload when a
+// GroupInfo event carries membership changes; nil for pure metadata
+// updates.
+func groupParticipantsData(instanceID string, e *events.GroupInfo) map[string]interface{} {
+	if len(e.Join)+len(e.Leave)+len(e.Promote)+len(e.Demote) == 0 {
+		return nil
+	}
+	data := map[string]interface{}{
+		"instance_id": instanceID,
+		"group":       e.JID.String(),
+		"timestamp":   e.Timestamp,
+	}
+	if e.Sender != nil {
+		data["actor"] = e.Sender.ToNonAD().String()
+	}
+	for key, jids := range map[string][]types.JID{
+		"added":    e.Join,
+		"removed":  e.Leave,
+		"promoted": e.Promote,
+		"demoted":  e.Demote,
+	} {
+		if len(jids) == 0 {
+			continue
+		}
+		list := make([]string, 0, len(jids))
+		for _, jid := range jids {
+			list = append(list, jid.ToNonAD().String())
+		}
+		data[key] = list
+	}
+	return data
+}
+
+// buttonResponseData recognizes button, template-button and list replies
+// and builds the message.button_response payload, including the ID of the
+// message the user responded to. Returns nil for ordinary messages.
+func buttonResponseData(instanceID string, e *events.Message) map[string]interface{} {
+	var selectedID, selectedText, contextID string
+	switch {
+	case e.Message.GetButtonsResponseMessage() != nil:
+		r := e.Message.GetButtonsResponseMessage()
+		selectedID = r.GetSelectedButtonID()
+		selectedText = r.GetSelectedDisplayText()
+		contextID = r.GetContextInfo().GetStanzaID()
+	case e.Message.GetTemplateButtonReplyMessage() != nil:
+		r := e.Message.GetTemplateButtonReplyMessage()
+		selectedID = r.GetSelectedID()
+		selectedText = r.GetSelectedDisplayText()
+		contextID = r.GetContextInfo().GetStanzaID()
+	case e.Message.GetListResponseMessage() != nil:
+		r := e.Message.GetListResponseMessage()
+		selectedID = r.GetSingleSelectReply().GetSelectedRowID()
+		selectedText = r.GetTitle()
+		contextID = r.GetContextInfo().GetStanzaID()
+	default:
+		return nil
+	}
+	return map[string]interface{}{
+		"instance_id":        instanceID,
+		"message_id":         e.Info.ID,
+		"chat":               e.Info.Chat.String(),
+		"sender":             e.Info.Sender.String(),
+		"selected_id":        selectedID,
+		"selected_text":      selectedText,
+		"context_message_id": contextID,
+		"timestamp":          e.Info.Timestamp,
+	}
+}
+
+// extractMessageData builds the message.received payload.
+func (ws *WebhookService) extractMessageData(instanceID string, e *events.Message) map[string]interface{} {
+	data := map[string]interface{}{
+		"instance_id": instanceID,
+		"message_id":  e.Info.ID,
+		"chat":        e.Info.Chat.String(),
+		"sender":      e.Info.Sender.String(),
+		"push_name":   e.Info.PushName,
+		"from_me":     e.Info.IsFromMe,
+		"is_group":    e.Info.IsGroup,
+		"type":        utils.MessageType(e.Message),
+		"content":     utils.MessageContent(e.Message),
+		"timestamp":   e.Info.Timestamp,
+	}
+	if ad := utils.AdContext(e.Message); ad != nil {
+		// Click-to-WhatsApp attribution for lead tracking.
+		data["ad_context"] = ad
+	}
+	if path, ok := ws.takeStoredMedia(instanceID, e.Info.ID); ok {
+		data["stored_media_path"] = path
+	}
+	if media := utils.MediaInfo(e.Message); media != nil {
+		data["mimetype"] = media.Mimetype
+		// The full encrypted-media bundle, shaped like the download
+		// endpoint's request so consumers can fetch the payload later
+		// without keeping the raw event around.
+		data["media"] = map[string]interface{}{
+			"media_url":       media.URL,
+			"direct_path":     media.DirectPath,
+			"media_key":       media.MediaKey,
+			"file_enc_sha256": media.FileEncSHA256,
+			"file_sha256":     media.FileSHA256,
+			"file_length":     media.FileLength,
+			"mimetype":        media.Mimetype,
+		}
+	}
+	return data
+}
+
+// throttlePresence reports whether a presence event should be dropped
+// for a webhook because the same contact fired one too recently.
+// Presence is by far the noisiest event type, so each webhook gets at
+// most one event per contact per window: the webhook's
+// presence_throttle_seconds when positive, the 10-second default when
+// zero; a negative value disables throttling.
+func (ws *WebhookService) throttlePresence(webhook *models.Webhook, data map[string]interface{}) bool {
+	window := defaultPresenceThrottle
+	if webhook.PresenceThrottleSeconds > 0 {
+		window = time.Duration(webhook.PresenceThrottleSeconds) * time.Second
+	} else if webhook.PresenceThrottleSeconds < 0 {
+		return false
+	}
+	contact, _ := data["from"].(string)
+	key := webhook.ID + "|" + contact
+	now := time.Now()
+	ws.presenceMu.Lock()
+	defer ws.presenceMu.Unlock()
+	if last, ok := ws.presenceSent[key]; ok && now.Sub(last) < window {
+		return true
+	}
+	ws.presenceSent[key] = now
+	return false
+}
+
+// pollVoteData shapes a poll.vote payload. The selected options are only
+// present when the event worker decrypted the vote first (polls created
+// through this API); otherwise the payload still identifies the poll and
+// voter so consumers know a vote happened.
+func (ws *WebhookService) pollVoteData(instanceID string, e *events.Message) map[string]interface{} {
+	data := map[string]interface{}{
+		"instance_id":     instanceID,
+		"poll_message_id": e.Message.GetPollUpdateMessage().GetPollCreationMessageKey().GetID(),
+		"voter":           e.Info.Sender.ToNonAD().String(),
+		"chat":            e.Info.Chat.String(),
+		"timestamp":       e.Info.Timestamp,
+	}
+	if selected, ok := ws.takePollVote(instanceID, e.Info.ID); ok {
+		data["selected_options"] = selected
+	}
+	return data
+}
+
+// RecordPollVote hands over a decrypted vote's option names so the
+// poll.vote payload can carry them. Keyed by the update message's own ID,
+// taken once when the payload is built.
+func (ws *WebhookService) RecordPollVote(instanceID, updateMessageID string, selected []string) {
+	ws.pollVotesMu.Lock()
+	defer ws.pollVotesMu.Unlock()
+	ws.pollVotes[instanceID+"|"+updateMessageID] = selected
+}
+
+// takePollVote returns and forgets the decrypted options for an update
+// message.
+func (ws *WebhookService) takePollVote(instanceID, updateMessageID string) ([]string, bool) {
+	ws.pollVotesMu.Lock()
+	defer ws.pollVotesMu.Unlock()
+	key := instanceID + "|" + updateMessageID
+	selected, ok := ws.pollVotes[key]
+	if ok {
+		delete(ws.pollVotes, key)
+	}
+	return selected, ok
+}
+
+// RecordStoredMedia remembers where a message's auto-downloaded media was
+// written so the message.received payload can point at it. The event
+// worker calls this before the webhook handler sees the message.
+func (ws *WebhookService) RecordStoredMedia(instanceID, messageID, path string) {
+	ws.storedMediaMu.Lock()
+	defer ws.storedMediaMu.Unlock()
+	ws.storedMedia[instanceID+"|"+messageID] = path
+}
+
+// takeStoredMedia returns and forgets the stored path for a message.
+func (ws *WebhookService) takeStoredMedia(instanceID, messageID string) (string, bool) {
+	ws.storedMediaMu.Lock()
+	defer ws.storedMediaMu.Unlock()
+	key := instanceID + "|" + messageID
+	path, ok := ws.storedMedia[key]
+	if ok {
+		delete(ws.storedMedia, key)
+	}
+	return path, ok
+}
+
+func receiptTypeName(e *events.Receipt) string {
+	switch e.Type {
+	case types.ReceiptTypeRead, types.ReceiptTypeReadSelf:
+		return "read"
+	case types.ReceiptTypePlayed:
+		return "played"
+	default:
+		return "delivered"
+	}
+}
+
+// Dispatch delivers an already-classified event to every active webhook of
+// the instance whose subscription and filters match, plus any global
+// (instance_id "*") webhooks.
+func (ws *WebhookService) Dispatch(instanceID, eventType string, data map[string]interface{}) {
+	var webhooks []models.Webhook
+	if err := ws.db.Where("instance_id IN ? AND active = ?", []string{instanceID, GlobalInstanceID}, true).Find(&webhooks).Error; err != nil {
+		log.Printf("webhook: loading webhooks for instance %s: %v", instanceID, err)
+		return
+	}
+	for i := range webhooks {
+		webhook := webhooks[i]
+		if !ws.isSubscribed(&webhook, eventType) || !ws.matchesFilters(webhook.ID, data) || ws.isMuted(webhook.ID, eventType) {
+			continue
+		}
+		if eventType == "contact.presence" && ws.throttlePresence(&webhook, data) {
+			continue
+		}
+		go func(webhook models.Webhook) {
+			release := ws.acquireDeliverySlot(instanceID)
+			defer release()
+			ws.deliver(&webhook, eventType, data)
+		}(webhook)
+	}
+}
+
+// DeliveryOverrides returns an instance's webhook delivery overrides from
+// the database; zeros mean the global config applies.
+func (ws *WebhookService) DeliveryOverrides(instanceID string) (concurrency, timeoutSeconds int) {
+	var instance models.Instance
+	if err := ws.db.Select("webhook_concurrency", "webhook_timeout_seconds").
+		First(&instance, "id = ?", instanceID).Error; err != nil {
+		return 0, 0
+	}
+	return instance.WebhookConcurrency, instance.WebhookTimeoutSeconds
+}
+
+// EffectiveDeliverySettings resolves an instance's overrides against the
+// global config into the values actually used for its deliveries.
+func (ws *WebhookService) EffectiveDeliverySettings(instanceID string) (concurrency, timeoutSeconds int) {
+	cfg := config.Get()
+	concurrency, timeoutSeconds = ws.DeliveryOverrides(instanceID)
+	if concurrency <= 0 {
+		concurrency = cfg.WebhookConcurrency
+	}
+	if timeoutSeconds <= 0 {
+		timeoutSeconds = cfg.WebhookTimeoutSeconds
+	}
+	return concurrency, timeoutSeconds
+}
+
+// acquireDeliverySlot blocks until the instance has a free delivery slot
+// and returns the release function. With no effective limit it is a no-op.
+func (ws *WebhookService) acquireDeliverySlot(instanceID string) func() {
+	limit, _ := ws.EffectiveDeliverySettings(instanceID)
+	if limit <= 0 {
+		return func() {}
+	}
+	ws.slotsMu.Lock()
+	slots := ws.slots[instanceID]
+	if slots == nil || slots.limit != limit {
+		// Deliveries holding slots in a replaced semaphore release into
+		// the old channel, which is then garbage collected.
+		slots = &deliverySlots{limit: limit, ch: make(chan struct{}, limit)}
+		ws.slots[instanceID] = slots
+	}
+	ws.slotsMu.Unlock()
+	slots.ch <- struct{}{}
+	return func() { <-slots.ch }
+}
+
+// isMuted reports whether eventType is temporarily muted for a webhook.
+func (ws *WebhookService) isMuted(webhookID, eventType string) bool {
+	var count int64
+	ws.db.Model(&models.WebhookMute{}).
+		Where("webhook_id = ? AND event_type = ? AND expires_at > ?", webhookID, eventType, time.Now()).
+		Count(&count)
+	return count > 0
+}
+
+// ActiveMutes lists the mutes still in effect for a webhook, purging
+// expired rows along the way.
+func (ws *WebhookService) ActiveMutes(webhookID string) []models.WebhookMute {
+	ws.db.
+		Where("webhook_id = ? AND expires_at <= ?", webhookID, time.Now()).
+		Delete(&models.WebhookMute{})
+	var mutes []models.WebhookMute
+	ws.db.
+		Where("webhook_id = ? AND expires_at > ?", webhookID, time.Now()).
+		Order("event_type ASC").
+		Find(&mutes)
+	return mutes
+}
+
+// isSubscribed reports whether the webhook's event list covers eventType.
+func (ws *WebhookService) isSubscribed(webhook *models.Webhook, eventType string) bool {
+	var subscribed []string
+	if err := json.Unmarshal([]byte(webhook.Events), &subscribed); err != nil {
+		return false
+	}
+	for _, ev := range subscribed {
+		if ev == "*" || ev == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesFilters applies the webhook's filters (ANDed) to the payload.
+func (ws *WebhookService) matchesFilters(webhookID string, data map[string]interface{}) bool {
+	var filters []models.WebhookFilter
+	if err := ws.db.Where("webhook_id = ?", webhookID).Find(&filters).Error; err != nil {
+		return true
+	}
+	for _, f := range filters {
+		value := fmt.Sprintf("%v", data[f.Field])
+		switch f.Operator {
+		case "equals", "":
+			if value != f.Value {
+				return false
+			}
+		case "not_equals":
+			if value == f.Value {
+				return false
+			}
+		case "contains":
+			if !bytes.Contains([]byte(value), []byte(f.Value)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// deliver posts the event to one webhook, retrying on failure and logging
+// the outcome.
+func (ws *WebhookService) deliver(webhook *models.Webhook, eventType string, data map[string]interface{}) {
+	body, err := json.Marshal(map[string]interface{}{
+		"event": eventType,
+		"data":  data,
+	})
+	if err != nil {
+		return
+	}
+	if webhook.Transform != "" {
+		if transformed, err := renderTransform(webhook.Transform, eventType, data); err != nil {
+			log.Printf("webhook %s: transform failed, delivering default payload: %v", webhook.ID, err)
+		} else {
+			body = transformed
+		}
+	}
+	logEntry := models.WebhookLog{
+		WebhookID:  webhook.ID,
+		InstanceID: webhook.InstanceID,
+		EventType:  eventType,
+		Payload:    string(body),
+	}
+	maxAttempts := config.Get().WebhookMaxAttempts
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		logEntry.Attempts = attempt
+		status, err := ws.sendWebhookEvent(webhook, eventType, body)
+		logEntry.StatusCode = status
+		if err != nil {
+			logEntry.Error = err.Error()
+		} else {
+			logEntry.Error = ""
+		}
+		if err == nil && status >= 200 && status < 300 {
+			logEntry.Success = true
+			break
+		}
+		time.Sleep(time.Duration(attempt) * time.Second)
+	}
+	ws.db.Create(&logEntry)
+}
+
+// renderTransform executes a webhook's transform template against the
+// event, producing the reshaped delivery body.
+func renderTransform(source, eventType string, data map[string]interface{}) ([]byte, error) {
+	tmpl, err := template.New("transform").Parse(source)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, map[string]interface{}{
+		"Event": eventType,
+		"Data":  data,
+	}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// DeliverPayload performs one signed delivery attempt for an
+// already-encoded payload. The controller's test and retry endpoints go
+// through it so signing lives in exactly one place.
+func (ws *WebhookService) DeliverPayload(webhook *models.Webhook, eventType string, body []byte) (int, error) {
+	return ws.sendWebhookEvent(webhook, eventType, body)
+}
+
+// Redeliver posts a previously logged payload to the webhook again through
+// the normal signed delivery path and records the outcome on the log entry.
+func (ws *WebhookService) Redeliver(webhook *models.Webhook, logEntry *models.WebhookLog) bool {
+	status, err := ws.sendWebhookEvent(webhook, logEntry.EventType, []byte(logEntry.Payload))
+	logEntry.Attempts++
+	logEntry.StatusCode = status
+	logEntry.Success = err == nil && status >= 200 && status < 300
+	if err != nil {
+		logEntry.Error = err.Error()
+	} else {
+		logEntry.Error = ""
+	}
+	ws.db.Save(logEntry)
+	return logEntry.Success
+}
+
+// sendWebhookEvent performs one HTTP delivery attempt, signing the body
+// with the webhook secret.
+func (ws *WebhookService) sendWebhookEvent(webhook *models.Webhook, eventType string, body []byte) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-Event", eventType)
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	if webhook.Secret != "" {
+		algorithm := SignatureAlgorithm(webhook)
+		req.Header.Set("X-Webhook-Signature", algorithm+"="+SignPayload(algorithm, webhook.Secret, timestamp, body))
+	}
+	client := ws.HTTPClientFor(webhook)
+	// Per-instance timeout overrides apply to the instance's own webhooks;
+	// global ("*") webhooks always use the global timeout.
+	if _, seconds := ws.DeliveryOverrides(webhook.InstanceID); seconds > 0 {
+		timeout := time.Duration(seconds) * time.Second
+		if timeout != client.Timeout {
+			client = &http.Client{Timeout: timeout, Transport: client.Transport}
+		}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+// HTTPClientFor returns the HTTP client for deliveries to a webhook: the
+// shared client unless the webhook customizes TLS verification.
+func (ws *WebhookService) HTTPClientFor(webhook *models.Webhook) *http.Client {
+	if !webhook.InsecureTLS && webhook.CACertPath == "" {
+		return ws.client
+	}
+	tlsConfig := &tls.Config{}
+	if webhook.InsecureTLS {
+		log.Printf("webhook %s: TLS certificate verification is DISABLED for deliveries to %s", webhook.ID, webhook.URL)
+		tlsConfig.InsecureSkipVerify = true
+	}
+	if webhook.CACertPath != "" {
+		pem, err := os.ReadFile(webhook.CACertPath)
+		if err != nil {
+			log.Printf("webhook %s: reading CA bundle %s: %v; using system roots", webhook.ID, webhook.CACertPath, err)
+		} else {
+			pool := x509.NewCertPool()
+			if pool.AppendCertsFromPEM(pem) {
+				tlsConfig.RootCAs = pool
+			} else {
+				log.Printf("webhook %s: no certificates found in %s; using system roots", webhook.ID, webhook.CACertPath)
+			}
+		}
+	}
+	return &http.Client{
+		Timeout:   ws.client.Timeout,
+		Transport: &http.Transport{TLSClientConfig: tlsConfig},
+	}
+}
+
+// Supported webhook signature algorithms. SHA-256 is the default;
+// SHA-512 is offered for consumers that standardize on it.
+const (
+	SignatureSHA256 = "sha256"
+	SignatureSHA512 = "sha512"
+)
+
+// ValidSignatureAlgorithm reports whether the value names a supported
+// signing algorithm; empty means the sha256 default.
+func ValidSignatureAlgorithm(algorithm string) bool {
+	switch algorithm {
+	case "", SignatureSHA256, SignatureSHA512:
+		return true
+	}
+	return false
+}
+
+// SignatureAlgorithm resolves a webhook's effective signing algorithm,
+// which doubles as the X-Webhook-Signature prefix.
+func SignatureAlgorithm(webhook *models.Webhook) string {
+	if webhook.SignatureAlgorithm == SignatureSHA512 {
+		return SignatureSHA512
+	}
+	return SignatureSHA256
+}
+
+// SignPayload computes the hex HMAC of "<timestamp>.<body>" with the
+// webhook secret using the given algorithm, the signature scheme
+// consumers verify against.
+func SignPayload(algorithm, secret, timestamp string, body []byte) string {
+	newHash := sha256.New
+	if algorithm == SignatureSHA512 {
+		newHash = sha512.New
+	}
+	mac := hmac.New(newHash, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"go.mau.fi/whatsmeow/proto/waCommon"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/Layoxd/whatsapiGo/src/models"
+)
+
+func buttonResponseEvent(msg *waE2E.Message) *events.Message {
+	return &events.Message{
+		Info: types.MessageInfo{
+			ID: "3EB0RESPONSE",
+			MessageSource: types.MessageSource{
+				Chat:   types.NewJID("5511999999999", types.DefaultUserServer),
+				Sender: types.NewJID("5511999999999", types.DefaultUserServer),
+			},
+			Timestamp: time.Now(),
+		},
+		Message: msg,
+	}
+}
+
+func TestButtonResponseData(t *testing.T) {
+	evt := buttonResponseEvent(&waE2E.Message{
+		ButtonsResponseMessage: &waE2E.ButtonsResponseMessage{
+			Response: &waE2E.ButtonsResponseMessage_SelectedDisplayText{
+				SelectedDisplayText: "Yes, confirm",
+			},
+			SelectedButtonID: proto.String("btn_confirm"),
+			ContextInfo:      &waE2E.ContextInfo{StanzaID: proto.String("3EB0ORIGINAL")},
+		},
+	})
+
+	data := buttonResponseData("inst-1", evt)
+	if data == nil {
+		t.Fatal("buttonResponseData returned nil for a button reply")
+	}
+	if data["selected_id"] != "btn_confirm" {
+		t.Errorf("selected_id = %v, want btn_confirm", data["selected_id"])
+	}
+	if data["selected_text"] != "Yes, confirm" {
+		t.Errorf("selected_text = %v, want Yes, confirm", data["selected_text"])
+	}
+	if data["context_message_id"] != "3EB0ORIGINAL" {
+		t.Errorf("context_message_id = %v, want 3EB0ORIGINAL", data["context_message_id"])
+	}
+}
+
+func TestButtonResponseDataListReply(t *testing.T) {
+	evt := buttonResponseEvent(&waE2E.Message{
+		ListResponseMessage: &waE2E.ListResponseMessage{
+			Title: proto.String("Option B"),
+			SingleSelectReply: &waE2E.ListResponseMessage_SingleSelectReply{
+				SelectedRowID: proto.String("row_b"),
+			},
+			ContextInfo: &waE2E.ContextInfo{StanzaID: proto.String("3EB0LIST")},
+		},
+	})
+
+	data := buttonResponseData("inst-1", evt)
+	if data == nil {
+		t.Fatal("buttonResponseData returned nil for a list reply")
+	}
+	if data["selected_id"] != "row_b" || data["selected_text"] != "Option B" {
+		t.Errorf("got %v/%v, want row_b/Option B", data["selected_id"], data["selected_text"])
+	}
+}
+
+func TestButtonResponseDataPlainMessage(t *testing.T) {
+	evt := buttonResponseEvent(&waE2E.Message{Conversation: proto.String("hello")})
+	if data := buttonResponseData("inst-1", evt); data != nil {
+		t.Errorf("buttonResponseData = %v for a plain message, want nil", data)
+	}
+}
+
+func TestClassifyEventButtonResponse(t *testing.T) {
+	ws := &WebhookService{}
+	evt := buttonResponseEvent(&waE2E.Message{
+		ButtonsResponseMessage: &waE2E.ButtonsResponseMessage{
+			SelectedButtonID: proto.String("btn_1"),
+		},
+	})
+	eventType, _ := ws.classifyEvent("inst-1", evt)
+	if eventType != "message.button_response" {
+		t.Errorf("classifyEvent = %q, want message.button_response", eventType)
+	}
+}
+
+func TestRenderTransform(t *testing.T) {
+	data := map[string]interface{}{"chat": "123@s.whatsapp.net", "content": "hi"}
+	body, err := renderTransform(`{"type":"{{.Event}}","text":"{{.Data.content}}"}`, "message.received", data)
+	if err != nil {
+		t.Fatalf("renderTransform: %v", err)
+	}
+	want := `{"type":"message.received","text":"hi"}`
+	if string(body) != want {
+		t.Errorf("renderTransform = %s, want %s", body, want)
+	}
+
+	if _, err := renderTransform(`{{.Event.Bad}}`, "message.received", data); err == nil {
+		t.Error("renderTransform succeeded on a field access into a string, want error")
+	}
+}
+
+func TestThrottlePresence(t *testing.T) {
+	ws := NewWebhookService(nil)
+	webhook := &models.Webhook{ID: "wh-1"}
+	data := map[string]interface{}{"from": "5511999999999@s.whatsapp.net"}
+
+	if ws.throttlePresence(webhook, data) {
+		t.Error("first presence event should pass")
+	}
+	if !ws.throttlePresence(webhook, data) {
+		t.Error("second presence event within the window should be throttled")
+	}
+	if ws.throttlePresence(webhook, map[string]interface{}{"from": "5511888888888@s.whatsapp.net"}) {
+		t.Error("a different contact should not be throttled")
+	}
+
+	disabled := &models.Webhook{ID: "wh-2", PresenceThrottleSeconds: -1}
+	if ws.throttlePresence(disabled, data) || ws.throttlePresence(disabled, data) {
+		t.Error("a negative setting should disable throttling")
+	}
+}
+
+func TestClassifyEventPollVote(t *testing.T) {
+	ws := NewWebhookService(nil)
+	ws.RecordPollVote("inst-1", "3EB0RESPONSE", []string{"Pizza"})
+
+	evt := buttonResponseEvent(&waE2E.Message{
+		PollUpdateMessage: &waE2E.PollUpdateMessage{
+			PollCreationMessageKey: &waCommon.MessageKey{ID: proto.String("3EB0POLL")},
+		},
+	})
+	eventType, data := ws.classifyEvent("inst-1", evt)
+	if eventType != "poll.vote" {
+		t.Fatalf("classifyEvent = %q, want poll.vote", eventType)
+	}
+	if data["poll_message_id"] != "3EB0POLL" {
+		t.Errorf("poll_message_id = %v, want 3EB0POLL", data["poll_message_id"])
+	}
+	selected, _ := data["selected_options"].([]string)
+	if len(selected) != 1 || selected[0] != "Pizza" {
+		t.Errorf("selected_options = %v, want [Pizza]", data["selected_options"])
+	}
+}
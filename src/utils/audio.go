@@ -0,0 +1,28 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+)
+
+// ValidateVoiceNote checks that audio intended as a voice note (PTT) is
+// an OGG container carrying an Opus stream, which WhatsApp requires for
+// voice notes to render with the waveform player. Non-PTT audio is not
+// validated anywhere; callers should stay permissive there.
+func ValidateVoiceNote(data []byte) error {
+	if !bytes.HasPrefix(data, []byte("OggS")) {
+		return errors.New("voice notes must be OGG/Opus; transcode first, e.g. ffmpeg -i input -c:a libopus output.ogg")
+	}
+	// An Ogg page is a 27-byte header followed by the segment table and
+	// the packet data. The first packet of an Opus stream is the
+	// "OpusHead" identification header.
+	if len(data) < 27 {
+		return errors.New("voice note OGG container is truncated")
+	}
+	segments := int(data[26])
+	offset := 27 + segments
+	if len(data) < offset+8 || !bytes.Equal(data[offset:offset+8], []byte("OpusHead")) {
+		return errors.New("voice note codec must be Opus; transcode first, e.g. ffmpeg -i input.ogg -c:a libopus output.ogg")
+	}
+	return nil
+}
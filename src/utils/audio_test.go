@@ -0,0 +1,30 @@
+package utils
+
+import "testing"
+
+// oggOpusHeader builds a minimal first Ogg page whose only packet starts
+// with the given magic.
+func oggOpusHeader(packetMagic string) []byte {
+	page := make([]byte, 0, 27+1+len(packetMagic))
+	page = append(page, []byte("OggS")...)
+	page = append(page, make([]byte, 22)...) // version through checksum
+	page = append(page, 1)                   // one segment
+	page = append(page, byte(len(packetMagic)))
+	page = append(page, []byte(packetMagic)...)
+	return page
+}
+
+func TestValidateVoiceNote(t *testing.T) {
+	if err := ValidateVoiceNote(oggOpusHeader("OpusHead")); err != nil {
+		t.Errorf("valid OGG/Opus rejected: %v", err)
+	}
+	if err := ValidateVoiceNote([]byte("ID3\x04mp3 data")); err == nil {
+		t.Error("non-OGG data accepted")
+	}
+	if err := ValidateVoiceNote(oggOpusHeader("\x01vorbis")); err == nil {
+		t.Error("OGG/Vorbis accepted as voice note")
+	}
+	if err := ValidateVoiceNote([]byte("OggS")); err == nil {
+		t.Error("truncated OGG accepted")
+	}
+}
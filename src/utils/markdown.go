@@ -0,0 +1,49 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// styleMarkers maps segment style names to WhatsApp formatting markers.
+var styleMarkers = map[string]string{
+	"bold":          "*",
+	"italic":        "_",
+	"strikethrough": "~",
+	"monospace":     "```",
+}
+
+// WrapStyles wraps text in the WhatsApp markdown markers for the given
+// styles, outermost first. Unknown styles are an error.
+func WrapStyles(text string, styles []string) (string, error) {
+	for i := len(styles) - 1; i >= 0; i-- {
+		marker, ok := styleMarkers[styles[i]]
+		if !ok {
+			return "", fmt.Errorf("unknown style %q (valid: bold, italic, strikethrough, monospace)", styles[i])
+		}
+		text = marker + text + marker
+	}
+	return text, nil
+}
+
+// ValidateMarkdown checks that WhatsApp formatting markers are balanced.
+// Monospace markers (```) may span lines; the single-character markers
+// must pair up within each line, matching how WhatsApp renders them.
+func ValidateMarkdown(text string) error {
+	if strings.Count(text, "```")%2 != 0 {
+		return fmt.Errorf("unbalanced monospace marker (```)")
+	}
+	stripped := strings.ReplaceAll(text, "```", "")
+	for i, line := range strings.Split(stripped, "\n") {
+		for name, marker := range map[string]string{
+			"bold":          "*",
+			"italic":        "_",
+			"strikethrough": "~",
+		} {
+			if strings.Count(line, marker)%2 != 0 {
+				return fmt.Errorf("unbalanced %s marker (%s) on line %d", name, marker, i+1)
+			}
+		}
+	}
+	return nil
+}
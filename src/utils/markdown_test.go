@@ -0,0 +1,43 @@
+package utils
+
+import "testing"
+
+func TestValidateMarkdown(t *testing.T) {
+	valid := []string{
+		"plain text",
+		"*bold* and _italic_",
+		"~strike~ in one line\n*bold* in another",
+		"```code\nspanning lines```",
+		"",
+	}
+	for _, text := range valid {
+		if err := ValidateMarkdown(text); err != nil {
+			t.Errorf("ValidateMarkdown(%q) = %v, want nil", text, err)
+		}
+	}
+
+	invalid := []string{
+		"*unclosed bold",
+		"_italic across\nlines_",
+		"```odd monospace",
+		"one ~ tilde",
+	}
+	for _, text := range invalid {
+		if err := ValidateMarkdown(text); err == nil {
+			t.Errorf("ValidateMarkdown(%q) = nil, want error", text)
+		}
+	}
+}
+
+func TestWrapStyles(t *testing.T) {
+	got, err := WrapStyles("hi", []string{"bold", "italic"})
+	if err != nil {
+		t.Fatalf("WrapStyles: %v", err)
+	}
+	if want := "*_hi_*"; got != want {
+		t.Errorf("WrapStyles = %q, want %q", got, want)
+	}
+	if _, err := WrapStyles("hi", []string{"blink"}); err == nil {
+		t.Error("WrapStyles with unknown style: want error, got nil")
+	}
+}
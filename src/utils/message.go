@@ -0,0 +1,169 @@
+package utils
+
+import (
+	"go.mau.fi/whatsmeow/proto/waE2E"
+)
+
+// MediaBundle is the encrypted-media key bundle of a media message,
+// everything needed to download and decrypt the payload later.
+type MediaBundle struct {
+	URL           string
+	DirectPath    string
+	MediaKey      []byte
+	FileEncSHA256 []byte
+	FileSHA256    []byte
+	FileLength    uint64
+	Mimetype      string
+}
+
+// MessageType returns a short type name for a message ("text", "image",
+// "video", "audio", "document", "location", "contact", "reaction", ...).
+func MessageType(msg *waE2E.Message) string {
+	switch {
+	case msg == nil:
+		return "unknown"
+	case msg.GetConversation() != "" || msg.GetExtendedTextMessage() != nil:
+		return "text"
+	case msg.GetImageMessage() != nil:
+		return "image"
+	case msg.GetVideoMessage() != nil:
+		return "video"
+	case msg.GetAudioMessage() != nil:
+		return "audio"
+	case msg.GetDocumentMessage() != nil:
+		return "document"
+	case msg.GetStickerMessage() != nil:
+		return "sticker"
+	case msg.GetLocationMessage() != nil:
+		return "location"
+	case msg.GetContactMessage() != nil:
+		return "contact"
+	case msg.GetReactionMessage() != nil:
+		return "reaction"
+	case msg.GetPollCreationMessageV3() != nil || msg.GetPollCreationMessage() != nil:
+		return "poll"
+	case msg.GetPollUpdateMessage() != nil:
+		return "poll_update"
+	case msg.GetProtocolMessage() != nil:
+		return "protocol"
+	default:
+		return "unknown"
+	}
+}
+
+// MessageContent returns the human-readable content of a message: the text
+// for text messages, the caption for media, or an empty string.
+func MessageContent(msg *waE2E.Message) string {
+	switch {
+	case msg == nil:
+		return ""
+	case msg.GetConversation() != "":
+		return msg.GetConversation()
+	case msg.GetExtendedTextMessage() != nil:
+		return msg.GetExtendedTextMessage().GetText()
+	case msg.GetImageMessage() != nil:
+		return msg.GetImageMessage().GetCaption()
+	case msg.GetVideoMessage() != nil:
+		return msg.GetVideoMessage().GetCaption()
+	case msg.GetDocumentMessage() != nil:
+		return msg.GetDocumentMessage().GetCaption()
+	case msg.GetReactionMessage() != nil:
+		return msg.GetReactionMessage().GetText()
+	default:
+		return ""
+	}
+}
+
+// MediaInfo returns the encrypted-media bundle for media messages, or nil
+// for messages without downloadable media.
+func MediaInfo(msg *waE2E.Message) *MediaBundle {
+	switch {
+	case msg == nil:
+		return nil
+	case msg.GetImageMessage() != nil:
+		m := msg.GetImageMessage()
+		return &MediaBundle{m.GetURL(), m.GetDirectPath(), m.GetMediaKey(), m.GetFileEncSHA256(), m.GetFileSHA256(), m.GetFileLength(), m.GetMimetype()}
+	case msg.GetVideoMessage() != nil:
+		m := msg.GetVideoMessage()
+		return &MediaBundle{m.GetURL(), m.GetDirectPath(), m.GetMediaKey(), m.GetFileEncSHA256(), m.GetFileSHA256(), m.GetFileLength(), m.GetMimetype()}
+	case msg.GetAudioMessage() != nil:
+		m := msg.GetAudioMessage()
+		return &MediaBundle{m.GetURL(), m.GetDirectPath(), m.GetMediaKey(), m.GetFileEncSHA256(), m.GetFileSHA256(), m.GetFileLength(), m.GetMimetype()}
+	case msg.GetDocumentMessage() != nil:
+		m := msg.GetDocumentMessage()
+		return &MediaBundle{m.GetURL(), m.GetDirectPath(), m.GetMediaKey(), m.GetFileEncSHA256(), m.GetFileSHA256(), m.GetFileLength(), m.GetMimetype()}
+	case msg.GetStickerMessage() != nil:
+		m := msg.GetStickerMessage()
+		return &MediaBundle{m.GetURL(), m.GetDirectPath(), m.GetMediaKey(), m.GetFileEncSHA256(), m.GetFileSHA256(), m.GetFileLength(), m.GetMimetype()}
+	default:
+		return nil
+	}
+}
+
+// AdReferral is the click-to-WhatsApp ad context attached to a message,
+// extracted from the ExternalAdReplyInfo of its context info.
+type AdReferral struct {
+	Title             string `json:"title,omitempty"`
+	Body              string `json:"body,omitempty"`
+	MediaType         string `json:"media_type,omitempty"`
+	ThumbnailURL      string `json:"thumbnail_url,omitempty"`
+	MediaURL          string `json:"media_url,omitempty"`
+	SourceType        string `json:"source_type,omitempty"`
+	SourceID          string `json:"source_id,omitempty"`
+	SourceURL         string `json:"source_url,omitempty"`
+	CtwaClid          string `json:"ctwa_clid,omitempty"`
+	Ref               string `json:"ref,omitempty"`
+	ContainsAutoReply bool   `json:"contains_auto_reply,omitempty"`
+}
+
+// messageContextInfo returns the context info of whichever sub-message
+// carries one, or nil.
+func messageContextInfo(msg *waE2E.Message) *waE2E.ContextInfo {
+	switch {
+	case msg == nil:
+		return nil
+	case msg.GetExtendedTextMessage() != nil:
+		return msg.GetExtendedTextMessage().GetContextInfo()
+	case msg.GetImageMessage() != nil:
+		return msg.GetImageMessage().GetContextInfo()
+	case msg.GetVideoMessage() != nil:
+		return msg.GetVideoMessage().GetContextInfo()
+	case msg.GetAudioMessage() != nil:
+		return msg.GetAudioMessage().GetContextInfo()
+	case msg.GetDocumentMessage() != nil:
+		return msg.GetDocumentMessage().GetContextInfo()
+	case msg.GetStickerMessage() != nil:
+		return msg.GetStickerMessage().GetContextInfo()
+	default:
+		return nil
+	}
+}
+
+// AdContext extracts the inbound ad-referral context of a message, or nil
+// when the message did not come through an ad click.
+func AdContext(msg *waE2E.Message) *AdReferral {
+	contextInfo := messageContextInfo(msg)
+	if contextInfo == nil || contextInfo.GetExternalAdReply() == nil {
+		return nil
+	}
+	ad := contextInfo.GetExternalAdReply()
+	referral := &AdReferral{
+		Title:             ad.GetTitle(),
+		Body:              ad.GetBody(),
+		ThumbnailURL:      ad.GetThumbnailURL(),
+		MediaURL:          ad.GetMediaURL(),
+		SourceType:        ad.GetSourceType(),
+		SourceID:          ad.GetSourceID(),
+		SourceURL:         ad.GetSourceURL(),
+		CtwaClid:          ad.GetCtwaClid(),
+		Ref:               ad.GetRef(),
+		ContainsAutoReply: ad.GetContainsAutoReply(),
+	}
+	switch ad.GetMediaType() {
+	case waE2E.ContextInfo_ExternalAdReplyInfo_IMAGE:
+		referral.MediaType = "image"
+	case waE2E.ContextInfo_ExternalAdReplyInfo_VIDEO:
+		referral.MediaType = "video"
+	}
+	return referral
+}
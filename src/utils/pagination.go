@@ -0,0 +1,44 @@
+package utils
+
+// DefaultPageLimit is used when a list endpoint gets no explicit limit.
+const DefaultPageLimit = 50
+
+// MaxPageLimit caps the page size of list endpoints.
+const MaxPageLimit = 500
+
+// Pagination is the shared pagination metadata returned by list endpoints.
+type Pagination struct {
+	Page       int   `json:"page"`
+	Limit      int   `json:"limit"`
+	Total      int64 `json:"total"`
+	TotalPages int   `json:"total_pages"`
+	HasMore    bool  `json:"has_more"`
+}
+
+// NewPagination normalizes page/limit (page starts at 1, limit falls back
+// to DefaultPageLimit and is capped at MaxPageLimit) and computes the
+// derived fields from the total row count.
+func NewPagination(page, limit int, total int64) Pagination {
+	if page < 1 {
+		page = 1
+	}
+	if limit <= 0 {
+		limit = DefaultPageLimit
+	}
+	if limit > MaxPageLimit {
+		limit = MaxPageLimit
+	}
+	totalPages := int((total + int64(limit) - 1) / int64(limit))
+	return Pagination{
+		Page:       page,
+		Limit:      limit,
+		Total:      total,
+		TotalPages: totalPages,
+		HasMore:    page < totalPages,
+	}
+}
+
+// Offset is the row offset for the current page.
+func (p Pagination) Offset() int {
+	return (p.Page - 1) * p.Limit
+}
@@ -0,0 +1,51 @@
+package utils
+
+import "testing"
+
+func TestNewPaginationNormalizesPage(t *testing.T) {
+	p := NewPagination(0, 10, 25)
+	if p.Page != 1 {
+		t.Errorf("page 0 should normalize to 1, got %d", p.Page)
+	}
+	if p.Offset() != 0 {
+		t.Errorf("offset for first page should be 0, got %d", p.Offset())
+	}
+	if p.TotalPages != 3 {
+		t.Errorf("expected 3 total pages, got %d", p.TotalPages)
+	}
+	if !p.HasMore {
+		t.Error("expected has_more on first of three pages")
+	}
+}
+
+func TestNewPaginationLimitLargerThanTotal(t *testing.T) {
+	p := NewPagination(1, 100, 7)
+	if p.TotalPages != 1 {
+		t.Errorf("expected 1 total page, got %d", p.TotalPages)
+	}
+	if p.HasMore {
+		t.Error("expected has_more to be false when limit exceeds total")
+	}
+}
+
+func TestNewPaginationDefaultsAndCaps(t *testing.T) {
+	if p := NewPagination(1, 0, 10); p.Limit != DefaultPageLimit {
+		t.Errorf("limit 0 should fall back to default, got %d", p.Limit)
+	}
+	if p := NewPagination(1, MaxPageLimit+1, 10); p.Limit != MaxPageLimit {
+		t.Errorf("limit should be capped at %d, got %d", MaxPageLimit, p.Limit)
+	}
+}
+
+func TestNewPaginationEmptyTotal(t *testing.T) {
+	p := NewPagination(3, 10, 0)
+	if p.TotalPages != 0 {
+		t.Errorf("expected 0 total pages, got %d", p.TotalPages)
+	}
+	if p.HasMore {
+		t.Error("expected has_more false for empty result")
+	}
+	if p.Offset() != 20 {
+		t.Errorf("offset should still honor the requested page, got %d", p.Offset())
+	}
+}
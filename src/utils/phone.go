@@ -0,0 +1,26 @@
+package utils
+
+import (
+	"strings"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+// NormalizePhone strips formatting characters and a leading plus sign so
+// numbers compare and convert to JIDs consistently.
+func NormalizePhone(phone string) string {
+	replacer := strings.NewReplacer("+", "", " ", "", "-", "", "(", "", ")", "", ".", "")
+	return replacer.Replace(strings.TrimSpace(phone))
+}
+
+// UserJID converts a phone number into a user JID.
+func UserJID(phone string) types.JID {
+	return types.NewJID(NormalizePhone(phone), types.DefaultUserServer)
+}
+
+// GroupJID converts a group ID into a group JID. The ID may be given with
+// or without the @g.us server part.
+func GroupJID(id string) types.JID {
+	id = strings.TrimSuffix(strings.TrimSpace(id), "@"+types.GroupServer)
+	return types.NewJID(id, types.GroupServer)
+}
@@ -0,0 +1,21 @@
+package utils
+
+// ChunkStrings splits items into consecutive chunks of at most size
+// elements. A non-positive size yields a single chunk with everything.
+func ChunkStrings(items []string, size int) [][]string {
+	if len(items) == 0 {
+		return nil
+	}
+	if size <= 0 {
+		return [][]string{items}
+	}
+	chunks := make([][]string, 0, (len(items)+size-1)/size)
+	for start := 0; start < len(items); start += size {
+		end := start + size
+		if end > len(items) {
+			end = len(items)
+		}
+		chunks = append(chunks, items[start:end])
+	}
+	return chunks
+}
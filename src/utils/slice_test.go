@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestChunkStrings(t *testing.T) {
+	items := make([]string, 1050)
+	for i := range items {
+		items[i] = fmt.Sprintf("item-%d", i)
+	}
+
+	chunks := ChunkStrings(items, 100)
+	if len(chunks) != 11 {
+		t.Fatalf("len(chunks) = %d, want 11", len(chunks))
+	}
+	for i, chunk := range chunks[:10] {
+		if len(chunk) != 100 {
+			t.Errorf("chunk %d has %d elements, want 100", i, len(chunk))
+		}
+	}
+	if len(chunks[10]) != 50 {
+		t.Errorf("last chunk has %d elements, want 50", len(chunks[10]))
+	}
+	if chunks[10][49] != "item-1049" {
+		t.Errorf("last element = %q, want item-1049", chunks[10][49])
+	}
+}
+
+func TestChunkStringsEdgeCases(t *testing.T) {
+	if got := ChunkStrings(nil, 10); got != nil {
+		t.Errorf("ChunkStrings(nil) = %v, want nil", got)
+	}
+	if got := ChunkStrings([]string{"a", "b"}, 0); len(got) != 1 || len(got[0]) != 2 {
+		t.Errorf("ChunkStrings with size 0 = %v, want one chunk of 2", got)
+	}
+	if got := ChunkStrings([]string{"a", "b"}, 5); len(got) != 1 {
+		t.Errorf("ChunkStrings smaller than size = %v, want one chunk", got)
+	}
+}
@@ -0,0 +1,52 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// placeholderPattern matches {{name}} placeholders; names are limited to
+// word characters so literal braces in message text stay untouched.
+var placeholderPattern = regexp.MustCompile(`\{\{\s*(\w+)\s*\}\}`)
+
+// TemplateParams returns the distinct placeholder names used in a
+// template, sorted for stable output.
+func TemplateParams(content string) []string {
+	seen := make(map[string]bool)
+	params := make([]string, 0)
+	for _, match := range placeholderPattern.FindAllStringSubmatch(content, -1) {
+		if !seen[match[1]] {
+			seen[match[1]] = true
+			params = append(params, match[1])
+		}
+	}
+	sort.Strings(params)
+	return params
+}
+
+// RenderTemplate substitutes the given parameter values into a template.
+// Every placeholder must have a value; missing ones are reported in a
+// single error so the caller can fix them all at once.
+func RenderTemplate(content string, params map[string]string) (string, error) {
+	missingSeen := make(map[string]bool)
+	missing := make([]string, 0)
+	rendered := placeholderPattern.ReplaceAllStringFunc(content, func(match string) string {
+		name := placeholderPattern.FindStringSubmatch(match)[1]
+		value, ok := params[name]
+		if !ok {
+			if !missingSeen[name] {
+				missingSeen[name] = true
+				missing = append(missing, name)
+			}
+			return match
+		}
+		return value
+	})
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return "", fmt.Errorf("missing template params: %s", strings.Join(missing, ", "))
+	}
+	return rendered, nil
+}
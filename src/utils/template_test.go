@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTemplateParams(t *testing.T) {
+	params := TemplateParams("Hi {{ name }}, your order {{order_id}} ships to {{name}}.")
+	want := []string{"name", "order_id"}
+	if !reflect.DeepEqual(params, want) {
+		t.Errorf("TemplateParams = %v, want %v", params, want)
+	}
+	if got := TemplateParams("no placeholders"); len(got) != 0 {
+		t.Errorf("TemplateParams = %v, want empty", got)
+	}
+}
+
+func TestRenderTemplate(t *testing.T) {
+	out, err := RenderTemplate("Hi {{name}}, order {{order_id}} confirmed.", map[string]string{
+		"name":     "Ana",
+		"order_id": "42",
+	})
+	if err != nil {
+		t.Fatalf("RenderTemplate: %v", err)
+	}
+	if want := "Hi Ana, order 42 confirmed."; out != want {
+		t.Errorf("RenderTemplate = %q, want %q", out, want)
+	}
+
+	if _, err := RenderTemplate("Hi {{name}} {{name}}, bye {{other}}", nil); err == nil {
+		t.Error("expected error for missing params")
+	} else if want := "missing template params: name, other"; err.Error() != want {
+		t.Errorf("error = %q, want %q", err.Error(), want)
+	}
+}